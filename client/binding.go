@@ -0,0 +1,261 @@
+package maxbotapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы переменных сценария (Variable.Type)
+const (
+	VariableString  = "string"
+	VariableNumber  = "number"
+	VariableInteger = "integer"
+	VariableBoolean = "boolean"
+	VariableDate    = "date"
+)
+
+// Тег поля структуры с именем переменной: `var:"name"`.
+// Поля без тега используют имя поля, `var:"-"` пропускает поле.
+// С опцией `var:"name,omitempty"` VariablesFromStruct не передаёт нулевое
+// значение поля, и для переменной работают обязательность и значение
+// по умолчанию. Прочие поля передаются всегда, включая false, 0 и "".
+// Поле-указатель, равное nil, не передаётся.
+const variableTag = "var"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ValidateVariables проверяет переменные по определениям сценария:
+// наличие обязательных и соответствие значений типам. Возвращает копию
+// vars с подставленными значениями по умолчанию и все найденные ошибки.
+func ValidateVariables(vars Variables, defs map[string]Variable) (Variables, error) {
+	result := make(Variables, len(vars)+len(defs))
+	for name, value := range vars {
+		result[name] = value
+	}
+
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		def := defs[name]
+		value, ok := result[name]
+		if !ok {
+			switch {
+			case def.Default != "":
+				value = def.Default
+				result[name] = value
+			case def.Required:
+				errs = append(errs, fmt.Errorf("%w: %s is required", ErrInvalidVariable, name))
+				continue
+			default:
+				continue
+			}
+		}
+
+		if err := checkVariableType(def.Type, value); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %v", ErrInvalidVariable, name, err))
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func checkVariableType(typ string, value string) error {
+	var err error
+	switch typ {
+	case "", VariableString:
+	case VariableNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case VariableInteger:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case VariableBoolean:
+		_, err = strconv.ParseBool(value)
+	case VariableDate:
+		_, err = time.Parse(time.RFC3339, value)
+	default:
+		return fmt.Errorf("unknown type %q", typ)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, typ)
+	}
+	return nil
+}
+
+// BindVariables проверяет vars по defs (если заданы) и заполняет
+// поля структуры, на которую указывает dst
+func BindVariables(vars Variables, dst interface{}, defs map[string]Variable) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind variables: dst must be a non-nil pointer to struct, got %T", dst)
+	}
+
+	if defs != nil {
+		var err error
+		if vars, err = ValidateVariables(vars, defs); err != nil {
+			return err
+		}
+	}
+
+	var errs []error
+	forEachVariableField(rv.Elem(), func(name string, field reflect.Value, _ bool) {
+		value, ok := vars[name]
+		if !ok {
+			return
+		}
+		if err := setVariableField(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %v", ErrInvalidVariable, name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// VariablesFromStruct собирает переменные из полей структуры src
+// и проверяет их по defs (если заданы). Нулевые поля с опцией omitempty
+// и nil-указатели пропускаются.
+func VariablesFromStruct(src interface{}, defs map[string]Variable) (Variables, error) {
+	rv := reflect.ValueOf(src)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("variables from struct: src must be a struct, got %T", src)
+	}
+
+	vars := Variables{}
+	var errs []error
+	forEachVariableField(rv, func(name string, field reflect.Value, omitEmpty bool) {
+		if field.Kind() == reflect.Pointer && field.IsNil() || omitEmpty && field.IsZero() {
+			return
+		}
+		value, err := formatVariableField(field)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %v", ErrInvalidVariable, name, err))
+			return
+		}
+		vars[name] = value
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	if defs != nil {
+		return ValidateVariables(vars, defs)
+	}
+	return vars, nil
+}
+
+// Params преобразует переменные в параметры для StartScenario
+func (v Variables) Params() map[string]interface{} {
+	params := make(map[string]interface{}, len(v))
+	for name, value := range v {
+		params[name] = value
+	}
+	return params
+}
+
+func forEachVariableField(rv reflect.Value, fn func(name string, field reflect.Value, omitEmpty bool)) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		omitEmpty := false
+		if tag, ok := sf.Tag.Lookup(variableTag); ok {
+			if tag == "-" {
+				continue
+			}
+			tag, opts, _ := strings.Cut(tag, ",")
+			if tag != "" {
+				name = tag
+			}
+			omitEmpty = opts == "omitempty"
+		}
+		fn(name, rv.Field(i), omitEmpty)
+	}
+}
+
+func setVariableField(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setVariableField(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	if field.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func formatVariableField(field reflect.Value) (string, error) {
+	if field.Kind() == reflect.Pointer {
+		return formatVariableField(field.Elem())
+	}
+	if field.Type() == timeType {
+		return field.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("unsupported field type %s", field.Type())
+	}
+}
@@ -0,0 +1,98 @@
+package maxbotapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVariablesFromStructAppliesDefs(t *testing.T) {
+	defs := map[string]Variable{
+		"name": {Type: VariableString, Required: true},
+		"age":  {Type: VariableInteger, Default: "30"},
+		"vip":  {Type: VariableBoolean},
+	}
+
+	type params struct {
+		Name string `var:"name,omitempty"`
+		Age  int    `var:"age,omitempty"`
+		VIP  *bool  `var:"vip"`
+	}
+	no := false
+
+	tests := []struct {
+		name    string
+		src     params
+		want    Variables
+		wantErr bool
+	}{
+		{name: "empty struct misses required", src: params{}, wantErr: true},
+		{name: "default applied", src: params{Name: "Ann"}, want: Variables{"name": "Ann", "age": "30"}},
+		{name: "explicit value kept", src: params{Name: "Ann", Age: 41}, want: Variables{"name": "Ann", "age": "41"}},
+		{name: "explicit false via pointer", src: params{Name: "Ann", VIP: &no}, want: Variables{"name": "Ann", "age": "30", "vip": "false"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VariablesFromStruct(tt.src, defs)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidVariable) {
+					t.Fatalf("err = %v, want ErrInvalidVariable", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VariablesFromStruct: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestVariablesFromStructKeepsZeroValues(t *testing.T) {
+	type settings struct {
+		Enabled bool   `var:"enabled"`
+		Count   int    `var:"count"`
+		Note    string `var:"note"`
+		Skipped int    `var:"skipped,omitempty"`
+	}
+
+	vars, err := VariablesFromStruct(settings{}, nil)
+	if err != nil {
+		t.Fatalf("VariablesFromStruct: %v", err)
+	}
+	want := Variables{"enabled": "false", "count": "0", "note": ""}
+	if len(vars) != len(want) {
+		t.Fatalf("got %v, want %v", vars, want)
+	}
+	for k, v := range want {
+		if got, ok := vars[k]; !ok || got != v {
+			t.Errorf("%s = %q (present %v), want %q", k, got, ok, v)
+		}
+	}
+
+	back := settings{Enabled: true, Count: 5, Note: "x"}
+	if err := BindVariables(vars, &back, nil); err != nil {
+		t.Fatalf("BindVariables: %v", err)
+	}
+	if back != (settings{}) {
+		t.Errorf("round trip = %+v, want zero values", back)
+	}
+}
+
+func TestBindVariablesPointerField(t *testing.T) {
+	var dst struct {
+		Age *int `var:"age"`
+	}
+	if err := BindVariables(Variables{"age": "7"}, &dst, nil); err != nil {
+		t.Fatalf("BindVariables: %v", err)
+	}
+	if dst.Age == nil || *dst.Age != 7 {
+		t.Fatalf("Age = %v, want 7", dst.Age)
+	}
+}
@@ -0,0 +1,203 @@
+package maxbotapi
+
+import (
+	"html"
+	"strings"
+)
+
+// MessageBuilder пошагово собирает RichMessage.
+//
+//	msg := NewMessage().
+//		Text("Заказ ").Bold(orderID).Text(" готов").
+//		Markdown().
+//		Keyboard(rows).
+//		Build()
+//
+// Text добавляет разметку как есть, а Escaped, Bold, Italic, Code и Link
+// экранируют переданный текст под выбранный формат. Формат можно выбрать
+// в любой момент: текст собирается при вызове Build.
+type MessageBuilder struct {
+	parts []textPart
+	msg   RichMessage
+}
+
+type textPart struct {
+	kind  textPartKind
+	text  string
+	extra string
+}
+
+type textPartKind int
+
+const (
+	partRaw textPartKind = iota
+	partEscaped
+	partBold
+	partItalic
+	partCode
+	partLink
+)
+
+// NewMessage создаёт пустой построитель сообщения
+func NewMessage() *MessageBuilder {
+	return &MessageBuilder{}
+}
+
+// Text добавляет текст без экранирования
+func (b *MessageBuilder) Text(text string) *MessageBuilder {
+	return b.add(partRaw, text, "")
+}
+
+// Escaped добавляет пользовательский текст, экранированный под формат сообщения
+func (b *MessageBuilder) Escaped(text string) *MessageBuilder {
+	return b.add(partEscaped, text, "")
+}
+
+func (b *MessageBuilder) Bold(text string) *MessageBuilder {
+	return b.add(partBold, text, "")
+}
+
+func (b *MessageBuilder) Italic(text string) *MessageBuilder {
+	return b.add(partItalic, text, "")
+}
+
+func (b *MessageBuilder) Code(text string) *MessageBuilder {
+	return b.add(partCode, text, "")
+}
+
+func (b *MessageBuilder) Link(title, url string) *MessageBuilder {
+	return b.add(partLink, title, url)
+}
+
+func (b *MessageBuilder) Markdown() *MessageBuilder {
+	b.msg.Format = FormatMarkdown
+	return b
+}
+
+func (b *MessageBuilder) HTML() *MessageBuilder {
+	b.msg.Format = FormatHTML
+	return b
+}
+
+func (b *MessageBuilder) Plain() *MessageBuilder {
+	b.msg.Format = FormatPlain
+	return b
+}
+
+// Keyboard задаёт клавиатуру сообщения
+func (b *MessageBuilder) Keyboard(rows [][]Button) *MessageBuilder {
+	b.msg.Buttons = rows
+	return b
+}
+
+// Attach добавляет вложения
+func (b *MessageBuilder) Attach(attachments ...Attachment) *MessageBuilder {
+	b.msg.Attachments = append(b.msg.Attachments, attachments...)
+	return b
+}
+
+// ReplyTo делает сообщение ответом на сообщение с указанным ID
+func (b *MessageBuilder) ReplyTo(messageID string) *MessageBuilder {
+	b.msg.ReplyTo = messageID
+	return b
+}
+
+// Build возвращает готовое сообщение для SendMessage
+func (b *MessageBuilder) Build() *RichMessage {
+	msg := b.msg
+	msg.Text = b.render(msg.Format)
+	msg.Attachments = append([]Attachment(nil), b.msg.Attachments...)
+	return &msg
+}
+
+func (b *MessageBuilder) add(kind textPartKind, text, extra string) *MessageBuilder {
+	b.parts = append(b.parts, textPart{kind: kind, text: text, extra: extra})
+	return b
+}
+
+func (b *MessageBuilder) render(format TextFormat) string {
+	var sb strings.Builder
+	for _, p := range b.parts {
+		if p.kind == partRaw {
+			sb.WriteString(p.text)
+			continue
+		}
+
+		switch format {
+		case FormatMarkdown:
+			sb.WriteString(renderMarkdown(p))
+		case FormatHTML:
+			sb.WriteString(renderHTML(p))
+		default:
+			sb.WriteString(p.text)
+			if p.kind == partLink && p.extra != "" {
+				sb.WriteString(" (" + p.extra + ")")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func renderMarkdown(p textPart) string {
+	switch p.kind {
+	case partBold:
+		return "*" + EscapeMarkdown(p.text) + "*"
+	case partItalic:
+		return "_" + EscapeMarkdown(p.text) + "_"
+	case partCode:
+		return "`" + markdownCodeReplacer.Replace(p.text) + "`"
+	case partLink:
+		return "[" + EscapeMarkdown(p.text) + "](" + markdownURLReplacer.Replace(p.extra) + ")"
+	default:
+		return EscapeMarkdown(p.text)
+	}
+}
+
+func renderHTML(p textPart) string {
+	switch p.kind {
+	case partBold:
+		return "<b>" + EscapeHTML(p.text) + "</b>"
+	case partItalic:
+		return "<i>" + EscapeHTML(p.text) + "</i>"
+	case partCode:
+		return "<code>" + EscapeHTML(p.text) + "</code>"
+	case partLink:
+		return `<a href="` + EscapeHTML(p.extra) + `">` + EscapeHTML(p.text) + "</a>"
+	default:
+		return EscapeHTML(p.text)
+	}
+}
+
+var (
+	// Полный набор символов, которые MarkdownV2 считает служебными
+	markdownReplacer = strings.NewReplacer(
+		`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "~", `\~`,
+		"[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "{", `\{`, "}", `\}`,
+		"#", `\#`, ">", `\>`, "|", `\|`, "-", `\-`, "+", `\+`,
+		".", `\.`, "!", `\!`, "=", `\=`,
+	)
+	markdownCodeReplacer = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	markdownURLReplacer  = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+// EscapeMarkdown экранирует служебные символы Markdown
+func EscapeMarkdown(text string) string {
+	return markdownReplacer.Replace(text)
+}
+
+// EscapeHTML экранирует текст для HTML-разметки
+func EscapeHTML(text string) string {
+	return html.EscapeString(text)
+}
+
+// Escape экранирует текст под указанный формат
+func Escape(format TextFormat, text string) string {
+	switch format {
+	case FormatMarkdown:
+		return EscapeMarkdown(text)
+	case FormatHTML:
+		return EscapeHTML(text)
+	default:
+		return text
+	}
+}
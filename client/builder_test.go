@@ -0,0 +1,36 @@
+package maxbotapi
+
+import "testing"
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"- item", `\- item`},
+		{"1. foo", `1\. foo`},
+		{"+ bar", `\+ bar`},
+		{"a = b!", `a \= b\!`},
+		{"{x}", `\{x\}`},
+		{"*bold* _it_ `code`", "\\*bold\\* \\_it\\_ \\`code\\`"},
+		{`[link](url)`, `\[link\]\(url\)`},
+		{`# > | ~`, `\# \> \| \~`},
+		{`back\slash`, `back\\slash`},
+	}
+	for _, tt := range tests {
+		if got := EscapeMarkdown(tt.in); got != tt.want {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMessageBuilderMarkdown(t *testing.T) {
+	msg := NewMessage().Markdown().Bold("1. Итог").Text(" ").Escaped("- пункт").Build()
+	want := `*1\. Итог* \- пункт`
+	if msg.Text != want {
+		t.Errorf("Text = %q, want %q", msg.Text, want)
+	}
+	if msg.Format != FormatMarkdown {
+		t.Errorf("Format = %q, want %q", msg.Format, FormatMarkdown)
+	}
+}
@@ -0,0 +1,95 @@
+package maxbotapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Длина подписи callback-данных в байтах (усечённый HMAC-SHA256)
+const callbackSignatureSize = 16
+
+// CallbackCodec кодирует структуры в значения callback-кнопок и обратно.
+// Значение имеет вид base64url(JSON) или base64url(JSON).base64url(HMAC),
+// если задан секрет. Подписанные данные с неверной подписью отклоняются.
+type CallbackCodec struct {
+	secret []byte
+}
+
+// NewCallbackCodec создаёт кодек. При пустом secret значения не подписываются.
+func NewCallbackCodec(secret []byte) *CallbackCodec {
+	return &CallbackCodec{secret: secret}
+}
+
+// Encode сериализует v в значение кнопки, не превышающее MaxButtonValueLength
+func (c *CallbackCodec) Encode(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling callback payload: %w", err)
+	}
+
+	value := base64.RawURLEncoding.EncodeToString(data)
+	if len(c.secret) > 0 {
+		value += "." + base64.RawURLEncoding.EncodeToString(c.sign(data))
+	}
+
+	if len(value) > MaxButtonValueLength {
+		return "", fmt.Errorf("%w: %d bytes, max %d", ErrCallbackTooLarge, len(value), MaxButtonValueLength)
+	}
+	return value, nil
+}
+
+// Decode проверяет подпись и разбирает значение кнопки в v
+func (c *CallbackCodec) Decode(value string, v interface{}) error {
+	if len(value) > MaxButtonValueLength {
+		return fmt.Errorf("%w: %d bytes, max %d", ErrCallbackTooLarge, len(value), MaxButtonValueLength)
+	}
+
+	encoded, signature, signed := strings.Cut(value, ".")
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCallbackInvalid, err)
+	}
+
+	if len(c.secret) > 0 {
+		if !signed {
+			return ErrCallbackSigned
+		}
+		mac, err := base64.RawURLEncoding.DecodeString(signature)
+		if err != nil || !hmac.Equal(mac, c.sign(data)) {
+			return ErrCallbackSigned
+		}
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrCallbackInvalid, err)
+	}
+	return nil
+}
+
+// Button создаёт callback-кнопку с закодированным v
+func (c *CallbackCodec) Button(title string, v interface{}) (Button, error) {
+	value, err := c.Encode(v)
+	if err != nil {
+		return Button{}, err
+	}
+	return CallbackButton(title, value), nil
+}
+
+// DecodeEvent разбирает значение нажатой кнопки из события в v
+func (c *CallbackCodec) DecodeEvent(event *WebhookEvent, v interface{}) error {
+	click, err := event.ButtonClick()
+	if err != nil {
+		return err
+	}
+	return c.Decode(click.Value, v)
+}
+
+func (c *CallbackCodec) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(data)
+	return mac.Sum(nil)[:callbackSignatureSize]
+}
@@ -0,0 +1,99 @@
+package maxbotapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type callbackData struct {
+	Action string `json:"a"`
+	ID     int    `json:"id"`
+}
+
+func TestCallbackCodecRoundTrip(t *testing.T) {
+	for _, secret := range []string{"", "s3cret"} {
+		codec := NewCallbackCodec([]byte(secret))
+		value, err := codec.Encode(callbackData{Action: "buy", ID: 42})
+		if err != nil {
+			t.Fatalf("secret %q: Encode: %v", secret, err)
+		}
+		if signed := strings.Contains(value, "."); signed != (secret != "") {
+			t.Errorf("secret %q: value %q signed = %v", secret, value, signed)
+		}
+
+		var got callbackData
+		if err := codec.Decode(value, &got); err != nil {
+			t.Fatalf("secret %q: Decode: %v", secret, err)
+		}
+		if got != (callbackData{Action: "buy", ID: 42}) {
+			t.Errorf("secret %q: got %+v", secret, got)
+		}
+	}
+}
+
+func TestCallbackCodecRejects(t *testing.T) {
+	codec := NewCallbackCodec([]byte("s3cret"))
+	value, err := codec.Encode(callbackData{Action: "buy", ID: 1})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	forged, _ := NewCallbackCodec([]byte("other")).Encode(callbackData{Action: "buy", ID: 1000})
+	unsigned, _ := NewCallbackCodec(nil).Encode(callbackData{Action: "buy", ID: 1000})
+	payload, signature, _ := strings.Cut(value, ".")
+	tampered, _ := NewCallbackCodec(nil).Encode(callbackData{Action: "buy", ID: 2})
+	notJSON := base64.RawURLEncoding.EncodeToString([]byte("not json")) + "." +
+		base64.RawURLEncoding.EncodeToString(codec.sign([]byte("not json")))
+
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"wrong secret", forged, ErrCallbackSigned},
+		{"unsigned", unsigned, ErrCallbackSigned},
+		{"tampered payload", tampered + "." + signature, ErrCallbackSigned},
+		{"bad signature encoding", payload + ".!!", ErrCallbackSigned},
+		{"bad payload encoding", "!!." + signature, ErrCallbackInvalid},
+		{"signed but not json", notJSON, ErrCallbackInvalid},
+		{"too large", strings.Repeat("a", MaxButtonValueLength+1), ErrCallbackTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got callbackData
+			if err := codec.Decode(tt.value, &got); !errors.Is(err, tt.want) {
+				t.Fatalf("Decode() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackCodecEncodeTooLarge(t *testing.T) {
+	_, err := NewCallbackCodec(nil).Encode(strings.Repeat("x", MaxButtonValueLength))
+	if !errors.Is(err, ErrCallbackTooLarge) {
+		t.Fatalf("Encode() = %v, want ErrCallbackTooLarge", err)
+	}
+}
+
+func TestCallbackCodecDecodeEvent(t *testing.T) {
+	codec := NewCallbackCodec([]byte("s3cret"))
+	button, err := codec.Button("Buy", callbackData{Action: "buy", ID: 7})
+	if err != nil {
+		t.Fatalf("Button: %v", err)
+	}
+	data, _ := json.Marshal(ButtonClick{ButtonID: "b1", MessageID: "m1", Value: button.Value})
+
+	var got callbackData
+	if err := codec.DecodeEvent(&WebhookEvent{Type: EventButton, Data: data}, &got); err != nil {
+		t.Fatalf("DecodeEvent: %v", err)
+	}
+	if got.ID != 7 {
+		t.Errorf("got %+v", got)
+	}
+
+	if err := codec.DecodeEvent(&WebhookEvent{Type: EventMessage, Data: data}, &got); !errors.Is(err, ErrEventType) {
+		t.Errorf("DecodeEvent on message event = %v, want ErrEventType", err)
+	}
+}
@@ -0,0 +1,126 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ChatFilter условия выборки чатов. Пустые поля не ограничивают выборку.
+type ChatFilter struct {
+	Statuses    []ChatStatus
+	Type        string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	// Variables отбирает чаты, у которых переменные имеют указанные значения
+	Variables map[string]string
+	Limit     int
+}
+
+// ChatsPage страница результатов ListChatsPage
+type ChatsPage struct {
+	Chats      []Chat `json:"chats"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func (f ChatFilter) values() url.Values {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(f.Limit))
+	for _, status := range f.Statuses {
+		params.Add("status", string(status))
+	}
+	if f.Type != "" {
+		params.Set("type", f.Type)
+	}
+	setTime := func(key string, t time.Time) {
+		if !t.IsZero() {
+			params.Set(key, t.Format(time.RFC3339))
+		}
+	}
+	setTime("created_from", f.CreatedFrom)
+	setTime("created_to", f.CreatedTo)
+	setTime("updated_from", f.UpdatedFrom)
+	setTime("updated_to", f.UpdatedTo)
+	for name, value := range f.Variables {
+		params.Set("var."+name, value)
+	}
+	return params
+}
+
+// ListChatsPage возвращает одну страницу чатов, начиная с cursor
+func (c *Client) ListChatsPage(ctx context.Context, filter ChatFilter, cursor string) (*ChatsPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	params := filter.values()
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	url := fmt.Sprintf("%s/api/%s/chats?%s", c.baseURL, apiVersion, params.Encode())
+
+	var page ChatsPage
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &page)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// ListChats лениво обходит все чаты, подходящие под filter
+func (c *Client) ListChats(ctx context.Context, filter ChatFilter) iter.Seq2[Chat, error] {
+	return paginate(ctx, func(cursor string) ([]Chat, string, error) {
+		page, err := c.ListChatsPage(ctx, filter, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Chats, page.NextCursor, nil
+	})
+}
+
+// ChatStatusOptions необязательные сведения о смене статуса чата
+type ChatStatusOptions struct {
+	Reason   string            `json:"reason,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// CloseChat закрывает чат
+func (c *Client) CloseChat(ctx context.Context, chatID string, opts *ChatStatusOptions) error {
+	return c.changeChatStatus(ctx, chatID, "close", opts)
+}
+
+// ArchiveChat переносит чат в архив
+func (c *Client) ArchiveChat(ctx context.Context, chatID string, opts *ChatStatusOptions) error {
+	return c.changeChatStatus(ctx, chatID, "archive", opts)
+}
+
+// ReopenChat открывает закрытый или архивный чат
+func (c *Client) ReopenChat(ctx context.Context, chatID string, opts *ChatStatusOptions) error {
+	return c.changeChatStatus(ctx, chatID, "reopen", opts)
+}
+
+// ResolveChat отмечает обращение в чате как решённое
+func (c *Client) ResolveChat(ctx context.Context, chatID string, opts *ChatStatusOptions) error {
+	return c.changeChatStatus(ctx, chatID, "resolve", opts)
+}
+
+func (c *Client) changeChatStatus(ctx context.Context, chatID string, action string, opts *ChatStatusOptions) error {
+	if chatID == "" {
+		return ErrInvalidChatID
+	}
+	if opts == nil {
+		opts = &ChatStatusOptions{}
+	}
+
+	url := fmt.Sprintf("%s/api/%s/chats/%s/%s", c.baseURL, apiVersion, chatID, action)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "POST", url, opts, nil)
+	})
+}
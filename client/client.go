@@ -153,7 +153,15 @@ func (c *Client) GetUpdates(ctx context.Context, offset int64) ([]WebhookEvent,
 	return updates, nil
 }
 
-func (c *Client) SendMessage(ctx context.Context, chatID string, message interface{}) (*MessageResponse, error) {
+// SendMessage проверяет сообщение и отправляет его в чат
+func (c *Client) SendMessage(ctx context.Context, chatID string, message OutgoingMessage) (*MessageResponse, error) {
+	if chatID == "" {
+		return nil, ErrInvalidChatID
+	}
+	if err := validateMessage(message); err != nil {
+		return nil, err
+	}
+
 	url := fmt.Sprintf("%s/api/%s/chats/%s/messages", c.baseURL, apiVersion, chatID)
 
 	reqBody, err := json.Marshal(message)
@@ -244,36 +252,12 @@ func (c *Client) GetChat(ctx context.Context, chatID string) (*ChatInfo, error)
 	return &chat, nil
 }
 
+// GetMessages возвращает последние limit сообщений чата. При limit 0
+// сервер запрашивается с размером страницы по умолчанию (50), отрицательный
+// limit отклоняется с ErrInvalidLimit. Для обхода всей истории используйте
+// IterMessages.
 func (c *Client) GetMessages(ctx context.Context, chatID string, limit int) ([]Message, error) {
-	url := fmt.Sprintf("%s/api/%s/chats/%s/messages?limit=%d", c.baseURL, apiVersion, chatID, limit)
-
-	var messages []Message
-	err := c.retryRequest(ctx, func() error {
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			return fmt.Errorf("error creating request: %w", err)
-		}
-
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return fmt.Errorf("error sending request: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode >= 400 {
-			return c.parseAPIError(resp)
-		}
-
-		return json.NewDecoder(resp.Body).Decode(&messages)
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return messages, nil
+	return c.ListMessages(ctx, chatID, MessagesQuery{Limit: limit})
 }
 
 func (c *Client) StartScenario(ctx context.Context, chatID string, scenarioID string, params map[string]interface{}) (*ScenarioResponse, error) {
@@ -286,13 +270,19 @@ func (c *Client) StartScenario(ctx context.Context, chatID string, scenarioID st
 
 	var result ScenarioResponse
 	err = c.retryRequest(ctx, func() error {
-		return c.sendRequest(ctx, "POST", url, reqBody, &result)
+		return c.sendRequest(ctx, "POST", url, json.RawMessage(reqBody), &result)
 	})
 
 	return &result, err
 }
 
 func (c *Client) StopScenario(ctx context.Context, chatID string, scenarioID string) error {
+	if chatID == "" {
+		return ErrInvalidChatID
+	}
+	if err := checkScenarioID(scenarioID); err != nil {
+		return err
+	}
 	url := fmt.Sprintf("%s/api/%s/chats/%s/scenarios/%s/stop", c.baseURL, apiVersion, chatID, scenarioID)
 	return c.retryRequest(ctx, func() error {
 		return c.sendRequest(ctx, "POST", url, nil, nil)
@@ -301,25 +291,132 @@ func (c *Client) StopScenario(ctx context.Context, chatID string, scenarioID str
 
 // 2. Методы для работы с сообщениями
 func (c *Client) SendKeyboard(ctx context.Context, chatID string, text string, buttons [][]Button) (*MessageResponse, error) {
-	msg := struct {
-		Text    string     `json:"text"`
+	return c.SendMessage(ctx, chatID, KeyboardMessage{
+		Text:    text,
+		Buttons: buttons,
+	})
+}
+
+func (c *Client) SendCarousel(ctx context.Context, chatID string, items []CarouselItem) (*MessageResponse, error) {
+	return c.SendMessage(ctx, chatID, CarouselMessage{Carousel: items})
+}
+
+// Reply отправляет текстовый ответ на сообщение в том же чате
+func (c *Client) Reply(ctx context.Context, to *Message, text string) (*MessageResponse, error) {
+	if to == nil {
+		return nil, fmt.Errorf("%w: reply target is nil", ErrInvalidMessageID)
+	}
+	if err := checkMessageRef(to.ChatID, to.ID); err != nil {
+		return nil, err
+	}
+	return c.SendMessage(ctx, to.ChatID, TextMessage{Text: text, ReplyTo: to.ID})
+}
+
+// EditMessage заменяет содержимое отправленного сообщения
+func (c *Client) EditMessage(ctx context.Context, chatID string, messageID string, message OutgoingMessage) (*MessageResponse, error) {
+	if err := checkMessageRef(chatID, messageID); err != nil {
+		return nil, err
+	}
+	if err := validateMessage(message); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages/%s", c.baseURL, apiVersion, chatID, messageID)
+
+	var result MessageResponse
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "PUT", url, message, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// EditMessageKeyboard заменяет только клавиатуру сообщения; nil убирает её
+func (c *Client) EditMessageKeyboard(ctx context.Context, chatID string, messageID string, buttons [][]Button) error {
+	if err := checkMessageRef(chatID, messageID); err != nil {
+		return err
+	}
+	if err := ValidateKeyboard(buttons); err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages/%s/keyboard", c.baseURL, apiVersion, chatID, messageID)
+	body := struct {
 		Buttons [][]Button `json:"buttons"`
 	}{
-		Text:    text,
 		Buttons: buttons,
 	}
 
-	return c.SendMessage(ctx, chatID, msg)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "PUT", url, body, nil)
+	})
 }
 
-func (c *Client) SendCarousel(ctx context.Context, chatID string, items []CarouselItem) (*MessageResponse, error) {
-	return c.SendMessage(ctx, chatID, map[string]interface{}{
-		"carousel": items,
+// GetMessageStatus возвращает текущий статус доставки сообщения
+func (c *Client) GetMessageStatus(ctx context.Context, chatID string, messageID string) (*MessageStatus, error) {
+	if err := checkMessageRef(chatID, messageID); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages/%s/status", c.baseURL, apiVersion, chatID, messageID)
+
+	var status MessageStatus
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &status)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+func (c *Client) DeleteMessage(ctx context.Context, chatID string, messageID string) error {
+	if err := checkMessageRef(chatID, messageID); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages/%s", c.baseURL, apiVersion, chatID, messageID)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "DELETE", url, nil, nil)
+	})
+}
+
+func (c *Client) PinMessage(ctx context.Context, chatID string, messageID string) error {
+	if err := checkMessageRef(chatID, messageID); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages/%s/pin", c.baseURL, apiVersion, chatID, messageID)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "POST", url, nil, nil)
+	})
+}
+
+func (c *Client) UnpinMessage(ctx context.Context, chatID string, messageID string) error {
+	if err := checkMessageRef(chatID, messageID); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages/%s/pin", c.baseURL, apiVersion, chatID, messageID)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "DELETE", url, nil, nil)
+	})
+}
+
+// checkMessageRef не даёт пустым ID превратить путь сообщения в путь коллекции
+func checkMessageRef(chatID, messageID string) error {
+	if chatID == "" {
+		return ErrInvalidChatID
+	}
+	if messageID == "" {
+		return ErrInvalidMessageID
+	}
+	return nil
 }
 
 // 3. Методы управления чатами
-func (c *Client) SetChatVariables(ctx context.Context, chatID string, variables map[string]interface{}) error {
+// SetChatVariables полностью заменяет переменные чата
+func (c *Client) SetChatVariables(ctx context.Context, chatID string, variables Variables) error {
 	url := fmt.Sprintf("%s/api/%s/chats/%s/variables", c.baseURL, apiVersion, chatID)
 	return c.retryRequest(ctx, func() error {
 		return c.sendRequest(ctx, "PUT", url, variables, nil)
@@ -335,38 +432,55 @@ func (c *Client) TransferToAgent(ctx context.Context, chatID string, options Tra
 
 // 4. Вспомогательные методы
 func (c *Client) sendRequest(ctx context.Context, method string, url string, body interface{}, result interface{}) error {
+	_, err := c.sendRequestWithHeader(ctx, method, url, body, result, nil)
+	return err
+}
+
+// sendRequestWithHeader добавляет к запросу заголовки header и возвращает заголовки ответа
+func (c *Client) sendRequestWithHeader(ctx context.Context, method string, url string, body interface{}, result interface{}, header http.Header) (http.Header, error) {
 	var reqBody []byte
 	if body != nil {
 		var err error
 		reqBody, err = json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("error marshaling body: %w", err)
+			return nil, fmt.Errorf("error marshaling body: %w", err)
 		}
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(reqBody))
 	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
+		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error sending request: %w", err)
+		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// На условный запрос с If-Match несовпадение версии приходит как 412,
+	// а многие серверы отвечают 409. Для прочих запросов 409 означает
+	// обычный конфликт (например, повторное создание) и остаётся APIError.
+	if req.Header.Get("If-Match") != "" &&
+		(resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict) {
+		return resp.Header, fmt.Errorf("%w: %v", ErrVersionConflict, c.parseAPIError(resp))
+	}
+
 	if resp.StatusCode >= 400 {
-		return c.parseAPIError(resp)
+		return resp.Header, c.parseAPIError(resp)
 	}
 
 	if result != nil {
 		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("error decoding response: %w", err)
+			return resp.Header, fmt.Errorf("error decoding response: %w", err)
 		}
 	}
 
-	return nil
+	return resp.Header, nil
 }
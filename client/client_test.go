@@ -0,0 +1,90 @@
+package maxbotapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestClient возвращает клиента, направленного на тестовый сервер
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New("test-key", WithBaseURL(srv.URL), WithLogger(zap.NewNop()))
+}
+
+func TestMessageMethodsRejectEmptyMessageID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"EditMessage": func() error {
+			_, err := c.EditMessage(ctx, "chat", "", TextMessage{Text: "x"})
+			return err
+		},
+		"EditMessageKeyboard": func() error {
+			return c.EditMessageKeyboard(ctx, "chat", "", [][]Button{{CallbackButton("a", "b")}})
+		},
+		"GetMessageStatus": func() error {
+			_, err := c.GetMessageStatus(ctx, "chat", "")
+			return err
+		},
+		"DeleteMessage": func() error { return c.DeleteMessage(ctx, "chat", "") },
+		"PinMessage":    func() error { return c.PinMessage(ctx, "chat", "") },
+		"UnpinMessage":  func() error { return c.UnpinMessage(ctx, "chat", "") },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrInvalidMessageID) {
+			t.Errorf("%s: err = %v, want ErrInvalidMessageID", name, err)
+		}
+	}
+
+	if err := c.DeleteMessage(ctx, "", "m1"); !errors.Is(err, ErrInvalidChatID) {
+		t.Errorf("DeleteMessage with empty chat: err = %v, want ErrInvalidChatID", err)
+	}
+}
+
+func TestSendMessageRejectsTypedNil(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+
+	for _, msg := range []OutgoingMessage{nil, (*TextMessage)(nil), (*RichMessage)(nil)} {
+		if _, err := c.SendMessage(ctx, "chat", msg); !errors.Is(err, ErrEmptyMessage) {
+			t.Errorf("SendMessage(%T): err = %v, want ErrEmptyMessage", msg, err)
+		}
+		if _, err := c.EditMessage(ctx, "chat", "m1", msg); !errors.Is(err, ErrEmptyMessage) {
+			t.Errorf("EditMessage(%T): err = %v, want ErrEmptyMessage", msg, err)
+		}
+	}
+}
+
+func TestReplyRejectsMissingTarget(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		to   *Message
+		want error
+	}{
+		{"nil target", nil, ErrInvalidMessageID},
+		{"no message ID", &Message{ChatID: "chat"}, ErrInvalidMessageID},
+		{"no chat ID", &Message{ID: "m1"}, ErrInvalidChatID},
+	}
+	for _, tt := range tests {
+		if _, err := c.Reply(ctx, tt.to, "hi"); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
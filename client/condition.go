@@ -0,0 +1,592 @@
+package maxbotapi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Условия переходов NextStep.Condition.
+//
+// Синтаксис:
+//
+//	age >= 18 && country == "RU"
+//	!(status == 'blocked') || vip
+//	phone =~ "^\\+7\\d{10}$"
+//	answer contains "да" || answer startsWith "ок"
+//
+// Операторы по убыванию приоритета: !, затем сравнения ==, !=, <, <=, >, >=,
+// =~ и !~ (регулярные выражения), contains, startsWith, endsWith, затем &&
+// и ||. Литералы: строки в одинарных или двойных кавычках, числа, true,
+// false, null. Идентификаторы ссылаются на переменные; допускаются точки
+// и префиксы state. и vars. Неизвестная переменная равна null.
+//
+// Значения переменных строковые: если обе стороны сравнения приводятся
+// к числам, сравнение числовое, иначе строковое. В логическом контексте
+// ложны null, false, пустая строка, "false" и "0".
+
+// Condition разобранное условие перехода
+type Condition struct {
+	src  string
+	root condNode
+}
+
+// ConditionError ошибка разбора условия с позицией в исходной строке
+type ConditionError struct {
+	Expr    string
+	Pos     int
+	Message string
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("%s %q at position %d: %s", ErrInvalidCondition, e.Expr, e.Pos, e.Message)
+}
+
+func (e *ConditionError) Unwrap() error {
+	return ErrInvalidCondition
+}
+
+// ParseCondition разбирает условие. Пустое условие всегда истинно.
+func ParseCondition(src string) (*Condition, error) {
+	if strings.TrimSpace(src) == "" {
+		return &Condition{src: src, root: literalNode{boolValue(true)}}, nil
+	}
+
+	tokens, err := lexCondition(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &condParser{src: src, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return &Condition{src: src, root: root}, nil
+}
+
+// EvalCondition разбирает и вычисляет условие; подходит как ConditionEvaluator
+func EvalCondition(src string, vars map[string]string) (bool, error) {
+	cond, err := ParseCondition(src)
+	if err != nil {
+		return false, err
+	}
+	return cond.Eval(vars)
+}
+
+// Eval вычисляет условие по значениям переменных
+func (c *Condition) Eval(vars map[string]string) (bool, error) {
+	v, err := c.root.eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("condition %q: %w", c.src, err)
+	}
+	return v.truthy(), nil
+}
+
+func (c *Condition) String() string {
+	return c.src
+}
+
+// Значения
+
+type valueKind int
+
+const (
+	kindNull valueKind = iota
+	kindString
+	kindNumber
+	kindBool
+)
+
+type condValue struct {
+	kind valueKind
+	str  string
+	num  float64
+	b    bool
+}
+
+func boolValue(b bool) condValue { return condValue{kind: kindBool, b: b} }
+
+func (v condValue) truthy() bool {
+	switch v.kind {
+	case kindBool:
+		return v.b
+	case kindNumber:
+		return v.num != 0
+	case kindString:
+		return v.str != "" && v.str != "false" && v.str != "0"
+	default:
+		return false
+	}
+}
+
+func (v condValue) asNumber() (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, true
+	case kindString:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.str), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func (v condValue) asString() string {
+	switch v.kind {
+	case kindString:
+		return v.str
+	case kindNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case kindBool:
+		return strconv.FormatBool(v.b)
+	default:
+		return ""
+	}
+}
+
+func equalValues(a, b condValue) bool {
+	if a.kind == kindNull || b.kind == kindNull {
+		return a.kind == b.kind
+	}
+	if a.kind == kindBool || b.kind == kindBool {
+		return a.truthy() == b.truthy() && (a.kind == b.kind || isBoolString(a) || isBoolString(b))
+	}
+	if an, ok := a.asNumber(); ok {
+		if bn, ok := b.asNumber(); ok {
+			return an == bn
+		}
+	}
+	return a.asString() == b.asString()
+}
+
+func isBoolString(v condValue) bool {
+	return v.kind == kindString && (v.str == "true" || v.str == "false")
+}
+
+// compareValues возвращает -1, 0 или 1; ok=false, если значения несравнимы
+func compareValues(a, b condValue) (int, bool) {
+	if a.kind == kindNull || b.kind == kindNull {
+		return 0, false
+	}
+	if an, ok := a.asNumber(); ok {
+		if bn, ok := b.asNumber(); ok {
+			switch {
+			case an < bn:
+				return -1, true
+			case an > bn:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	return strings.Compare(a.asString(), b.asString()), true
+}
+
+// Узлы дерева
+
+type condNode interface {
+	eval(vars map[string]string) (condValue, error)
+}
+
+type literalNode struct {
+	value condValue
+}
+
+func (n literalNode) eval(map[string]string) (condValue, error) {
+	return n.value, nil
+}
+
+type varNode struct {
+	name string
+}
+
+func (n varNode) eval(vars map[string]string) (condValue, error) {
+	if value, ok := vars[n.name]; ok {
+		return condValue{kind: kindString, str: value}, nil
+	}
+	for _, prefix := range []string{"state.", "vars."} {
+		if name, ok := strings.CutPrefix(n.name, prefix); ok {
+			if value, ok := vars[name]; ok {
+				return condValue{kind: kindString, str: value}, nil
+			}
+		}
+	}
+	return condValue{}, nil
+}
+
+type notNode struct {
+	operand condNode
+}
+
+func (n notNode) eval(vars map[string]string) (condValue, error) {
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return condValue{}, err
+	}
+	return boolValue(!v.truthy()), nil
+}
+
+type logicalNode struct {
+	and         bool
+	left, right condNode
+}
+
+func (n logicalNode) eval(vars map[string]string) (condValue, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return condValue{}, err
+	}
+	if n.and != left.truthy() {
+		return boolValue(left.truthy()), nil
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return condValue{}, err
+	}
+	return boolValue(right.truthy()), nil
+}
+
+type compareNode struct {
+	op          string
+	left, right condNode
+	// re заранее скомпилированное выражение, если шаблон задан литералом
+	re *regexp.Regexp
+}
+
+func (n compareNode) eval(vars map[string]string) (condValue, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return condValue{}, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return condValue{}, err
+	}
+
+	switch n.op {
+	case "==":
+		return boolValue(equalValues(left, right)), nil
+	case "!=":
+		return boolValue(!equalValues(left, right)), nil
+	case "<", "<=", ">", ">=":
+		cmp, ok := compareValues(left, right)
+		if !ok {
+			return boolValue(false), nil
+		}
+		switch n.op {
+		case "<":
+			return boolValue(cmp < 0), nil
+		case "<=":
+			return boolValue(cmp <= 0), nil
+		case ">":
+			return boolValue(cmp > 0), nil
+		default:
+			return boolValue(cmp >= 0), nil
+		}
+	case "=~", "!~":
+		re := n.re
+		if re == nil {
+			re, err = regexp.Compile(right.asString())
+			if err != nil {
+				return condValue{}, fmt.Errorf("invalid regular expression: %w", err)
+			}
+		}
+		matched := left.kind != kindNull && re.MatchString(left.asString())
+		return boolValue(matched == (n.op == "=~")), nil
+	case "contains":
+		return boolValue(left.kind != kindNull && strings.Contains(left.asString(), right.asString())), nil
+	case "startsWith":
+		return boolValue(left.kind != kindNull && strings.HasPrefix(left.asString(), right.asString())), nil
+	case "endsWith":
+		return boolValue(left.kind != kindNull && strings.HasSuffix(left.asString(), right.asString())), nil
+	default:
+		return condValue{}, fmt.Errorf("unknown operator %s", n.op)
+	}
+}
+
+// Лексер
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type condToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t condToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+var wordOperators = map[string]bool{
+	"contains":   true,
+	"startsWith": true,
+	"endsWith":   true,
+}
+
+func lexCondition(src string) ([]condToken, error) {
+	var tokens []condToken
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, condToken{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, condToken{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					continue
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &ConditionError{Expr: src, Pos: start, Message: "unterminated string"}
+			}
+			tokens = append(tokens, condToken{kind: tokString, text: sb.String(), pos: start})
+			i++
+
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]) && expectsOperand(tokens)):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, &ConditionError{Expr: src, Pos: start, Message: fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, condToken{kind: tokNumber, text: text, pos: start})
+
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.' || runes[i] == '$') {
+				i++
+			}
+			text := string(runes[start:i])
+			kind := tokIdent
+			if wordOperators[text] {
+				kind = tokOp
+			}
+			tokens = append(tokens, condToken{kind: kind, text: strings.TrimPrefix(text, "$"), pos: start})
+
+		default:
+			start := i
+			op := ""
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "==", "!=", "<=", ">=", "&&", "||", "=~", "!~":
+					op = two
+				}
+			}
+			if op == "" {
+				switch r {
+				case '<', '>', '!':
+					op = string(r)
+				}
+			}
+			if op == "" {
+				return nil, &ConditionError{Expr: src, Pos: start, Message: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, condToken{kind: tokOp, text: op, pos: start})
+			i += len([]rune(op))
+		}
+	}
+
+	return append(tokens, condToken{kind: tokEOF, pos: len(runes)}), nil
+}
+
+// expectsOperand сообщает, что следующий токен должен быть операндом,
+// то есть минус относится к числу, а не является оператором
+func expectsOperand(tokens []condToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokOp || last.kind == tokLParen
+}
+
+// Парсер (рекурсивный спуск)
+
+type condParser struct {
+	src    string
+	tokens []condToken
+	pos    int
+}
+
+func (p *condParser) peek() condToken {
+	return p.tokens[p.pos]
+}
+
+func (p *condParser) next() condToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *condParser) errorf(tok condToken, format string, args ...interface{}) error {
+	return &ConditionError{Expr: p.src, Pos: tok.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *condParser) parseOr() (condNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseAnd() (condNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *condParser) parseUnary() (condNode, error) {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *condParser) parseComparison() (condNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	if tok.kind != tokOp {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~", "contains", "startsWith", "endsWith":
+	default:
+		return left, nil
+	}
+	p.next()
+
+	rightTok := p.peek()
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	node := compareNode{op: tok.text, left: left, right: right}
+	if tok.text == "=~" || tok.text == "!~" {
+		if lit, ok := right.(literalNode); ok {
+			node.re, err = regexp.Compile(lit.value.asString())
+			if err != nil {
+				return nil, p.errorf(rightTok, "invalid regular expression: %v", err)
+			}
+		}
+	}
+
+	if next := p.peek(); next.kind == tokOp && next.text != "&&" && next.text != "||" {
+		return nil, p.errorf(next, "comparisons cannot be chained; use && or parentheses")
+	}
+	return node, nil
+}
+
+func (p *condParser) parsePrimary() (condNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokString:
+		return literalNode{condValue{kind: kindString, str: tok.text}}, nil
+	case tokNumber:
+		f, _ := strconv.ParseFloat(tok.text, 64)
+		return literalNode{condValue{kind: kindNumber, num: f}}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return literalNode{boolValue(true)}, nil
+		case "false":
+			return literalNode{boolValue(false)}, nil
+		case "null":
+			return literalNode{condValue{}}, nil
+		}
+		return varNode{name: tok.text}, nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected ')', got %s", closing)
+		}
+		return inner, nil
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	default:
+		return nil, p.errorf(tok, "expected value, got %s", tok)
+	}
+}
@@ -0,0 +1,111 @@
+package maxbotapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	vars := map[string]string{
+		"age":     "30",
+		"country": "RU",
+		"status":  "blocked",
+		"vip":     "true",
+		"empty":   "",
+		"zero":    "0",
+		"phone":   "+79991234567",
+		"answer":  "да, конечно",
+		"pattern": "^\\+7",
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"age >= 18", true},
+		{"age < 18", false},
+		{"age == 30.0", true},
+		{"age > -1", true},
+		{"age >= 18 && country == \"RU\"", true},
+		{"age >= 18 && country == 'US'", false},
+		{"country == 'US' || vip", true},
+		{"!(status == 'blocked') || vip", true},
+		{"!vip", false},
+		{"empty", false},
+		{"zero", false},
+		{"missing", false},
+		{"missing == null", true},
+		{"missing != null", false},
+		{"missing < 5", false},
+		{"vip == true", true},
+		{"state.age > 18 && vars.country == 'RU'", true},
+		{"$age == 30", true},
+		{"country < 'US'", true},
+		{`phone =~ "^\\+7\\d{10}$"`, true},
+		{`phone !~ "^\\+7"`, false},
+		{"phone =~ pattern", true},
+		{"missing =~ '.*'", false},
+		{"answer contains 'да'", true},
+		{"answer startsWith 'да'", true},
+		{"answer endsWith 'нет'", false},
+		{"missing contains ''", false},
+		{"age > 18 && (country == 'US' || vip)", true},
+		{"country == 'US' && missing.field", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvalCondition(tt.expr, vars)
+			if err != nil {
+				t.Fatalf("EvalCondition: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("= %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{"age >", 5},
+		{`name == "ab`, 8},
+		{"a # b", 2},
+		{"(a == 1", 7},
+		{"a == 1 == 2", 7},
+		{`x =~ "("`, 5},
+		{"1.2.3 > 0", 0},
+		{"a b", 2},
+		{"a && ", 5},
+		{"возраст # 1", 8}, // позиция считается в символах, а не в байтах
+		{")", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCondition(tt.expr)
+			var cerr *ConditionError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("err = %v, want *ConditionError", err)
+			}
+			if !errors.Is(err, ErrInvalidCondition) {
+				t.Errorf("error does not match ErrInvalidCondition")
+			}
+			if cerr.Pos != tt.pos {
+				t.Errorf("Pos = %d, want %d (%v)", cerr.Pos, tt.pos, err)
+			}
+			if cerr.Expr != tt.expr {
+				t.Errorf("Expr = %q, want %q", cerr.Expr, tt.expr)
+			}
+		})
+	}
+}
+
+func TestConditionEvalRegexpError(t *testing.T) {
+	_, err := EvalCondition("name =~ pattern", map[string]string{"name": "x", "pattern": "("})
+	if err == nil {
+		t.Fatal("invalid regular expression from variable accepted")
+	}
+}
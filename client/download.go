@@ -0,0 +1,92 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DownloadAttachment скачивает файл вложения и записывает его в w.
+// Запрос повторяется до начала передачи данных; размер ограничен
+// теми же лимитами, что и при загрузке файлов этого типа.
+func (c *Client) DownloadAttachment(ctx context.Context, attachment IncomingAttachment, w io.Writer) (int64, error) {
+	limit, ok := uploadSizeLimits[attachment.Type]
+	if !ok {
+		limit = uploadSizeLimits[AttachmentFile]
+	}
+	if attachment.Size > limit {
+		return 0, fmt.Errorf("%w: %d bytes, max %d for %s", ErrFileTooLarge, attachment.Size, limit, attachment.Type)
+	}
+
+	fileURL, withAuth, err := c.attachmentURL(attachment)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp *http.Response
+	err = c.retryRequest(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
+		if err != nil {
+			return fmt.Errorf("error creating request: %w", err)
+		}
+
+		// Ключ API не передаём на сторонние хосты
+		if withAuth {
+			req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+
+		r, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("error sending request: %w", err)
+		}
+
+		if r.StatusCode == http.StatusTooManyRequests {
+			r.Body.Close()
+			return ErrRateLimit
+		}
+		if r.StatusCode >= 400 {
+			defer r.Body.Close()
+			return c.parseAPIError(r)
+		}
+
+		resp = r
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.ContentLength > limit {
+		return 0, fmt.Errorf("%w: %d bytes, max %d for %s", ErrFileTooLarge, resp.ContentLength, limit, attachment.Type)
+	}
+
+	n, err := io.Copy(w, io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return n, fmt.Errorf("error downloading file: %w", err)
+	}
+	if n > limit {
+		return n, fmt.Errorf("%w: more than %d bytes for %s", ErrFileTooLarge, limit, attachment.Type)
+	}
+
+	c.logger.Debug("Attachment downloaded",
+		zap.String("id", attachment.ID),
+		zap.Int64("bytes", n),
+	)
+	return n, nil
+}
+
+func (c *Client) attachmentURL(attachment IncomingAttachment) (string, bool, error) {
+	if attachment.ID != "" {
+		return fmt.Sprintf("%s/api/%s/files/%s", c.baseURL, apiVersion, url.PathEscape(attachment.ID)), true, nil
+	}
+	if attachment.URL == "" {
+		return "", false, fmt.Errorf("attachment has neither ID nor URL")
+	}
+	return attachment.URL, strings.HasPrefix(attachment.URL, c.baseURL+"/"), nil
+}
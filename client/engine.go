@@ -0,0 +1,718 @@
+package maxbotapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Максимальное число шагов, выполняемых подряд без ожидания ввода.
+// Защищает от бесконечных циклов из шагов-условий.
+const maxEngineTransitions = 1000
+
+// MessageSender отправляет сообщения от имени движка сценариев; *Client его реализует
+type MessageSender interface {
+	SendMessage(ctx context.Context, chatID string, message OutgoingMessage) (*MessageResponse, error)
+}
+
+// chatTransferer реализуется отправителем, умеющим переводить чат на оператора
+type chatTransferer interface {
+	TransferToAgent(ctx context.Context, chatID string, options TransferOptions) error
+}
+
+// ConditionEvaluator вычисляет NextStep.Condition по переменным сессии
+type ConditionEvaluator func(condition string, vars map[string]string) (bool, error)
+
+// StepHandler получает каждую завершённую запись StepExecution вместе
+// с копией сессии. Обработчик вызывается после снятия блокировок движка
+// в той горутине, которая выполняла шаг, поэтому может вызывать методы Engine.
+type StepHandler func(session ScenarioSession, execution StepExecution)
+
+// Engine выполняет сценарий локально, без серверной среды выполнения.
+// На каждый чат приходится не более одной сессии. События подаются
+// через HandleUpdate, ServePolling или HTTPHandler вместе с WebhookHandler.Handle.
+// ServePolling сам проверяет таймауты; при работе через HandleUpdate
+// или HTTPHandler нужно запустить RunTimeouts.
+//
+// Сессии разных чатов обрабатываются независимо: отправка сообщений
+// в одном чате не задерживает другие чаты и проверку таймаутов.
+type Engine struct {
+	scenario *Scenario
+	entry    string
+	sender   MessageSender
+	evaluate ConditionEvaluator
+	onStep   StepHandler
+	events   *ScenarioEventStream
+	now      func() time.Time
+	logger   *zap.Logger
+
+	// mu защищает только sessions; сессия блокируется своим engineSession.mu.
+	// Порядок захвата: engineSession.mu, затем Engine.mu.
+	mu       sync.Mutex
+	sessions map[string]*engineSession
+}
+
+type engineSession struct {
+	// mu удерживается на время обработки сессии, включая отправку сообщений
+	mu      sync.Mutex
+	session ScenarioSession
+	// waitUntil момент, когда истекает ожидание ввода или задержка
+	waitUntil time.Time
+	// waitingInput сессия ждёт сообщения пользователя на текущем шаге
+	waitingInput bool
+	// done сессия завершена и удалена из Engine.sessions
+	done bool
+	// pending вызовы обработчиков, отложенные до снятия mu
+	pending []func()
+}
+
+// snapshot возвращает копию сессии, не разделяющую изменяемые карты с движком
+func (s *engineSession) snapshot() ScenarioSession {
+	session := s.session
+	session.State = maps.Clone(s.session.State)
+	session.Chat.Variables = maps.Clone(s.session.Chat.Variables)
+	return session
+}
+
+type EngineOption func(*Engine)
+
+// WithConditionEvaluator заменяет вычислитель условий переходов
+func WithConditionEvaluator(evaluate ConditionEvaluator) EngineOption {
+	return func(e *Engine) {
+		e.evaluate = evaluate
+	}
+}
+
+// WithStepHandler задаёт обработчик записей о выполненных шагах
+func WithStepHandler(handler StepHandler) EngineOption {
+	return func(e *Engine) {
+		e.onStep = handler
+	}
+}
+
+// WithEventStream публикует события выполнения сессий в stream
+func WithEventStream(stream *ScenarioEventStream) EngineOption {
+	return func(e *Engine) {
+		e.events = stream
+	}
+}
+
+func WithEngineLogger(logger *zap.Logger) EngineOption {
+	return func(e *Engine) {
+		e.logger = logger
+	}
+}
+
+// WithClock подменяет источник времени, например в тестах
+func WithClock(now func() time.Time) EngineOption {
+	return func(e *Engine) {
+		e.now = now
+	}
+}
+
+// engineStepTypes типы шагов, которые умеет выполнять Engine
+var engineStepTypes = map[string]bool{
+	StepMessage:   true,
+	StepInput:     true,
+	StepCondition: true,
+	StepTransfer:  true,
+	StepDelay:     true,
+}
+
+// NewEngine проверяет сценарий и создаёт движок для его выполнения.
+// Шаги, которые движок не выполняет (StepAPICall и типы, добавленные
+// через RegisterStepPayload), а также StepTransfer при отправителе без
+// TransferToAgent отклоняются с *ScenarioValidationError.
+func NewEngine(scenario *Scenario, sender MessageSender, opts ...EngineOption) (*Engine, error) {
+	if err := ValidateScenario(scenario); err != nil {
+		return nil, err
+	}
+	if err := checkEngineSteps(scenario, sender); err != nil {
+		return nil, err
+	}
+	entry, err := scenario.EntryStep()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		scenario: scenario,
+		entry:    entry,
+		sender:   sender,
+		evaluate: EvalCondition,
+		now:      time.Now,
+		logger:   zap.NewNop(),
+		sessions: map[string]*engineSession{},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e, nil
+}
+
+func checkEngineSteps(scenario *Scenario, sender MessageSender) error {
+	_, canTransfer := sender.(chatTransferer)
+
+	var problems []ScenarioProblem
+	for _, id := range sortedStepIDs(scenario) {
+		step := scenario.Steps[id]
+		switch {
+		case !engineStepTypes[step.Type]:
+			problems = append(problems, ScenarioProblem{
+				Path:    "steps." + id + ".type",
+				Message: fmt.Sprintf("step type %q is not supported by the local engine", step.Type),
+			})
+		case step.Type == StepTransfer && !canTransfer:
+			problems = append(problems, ScenarioProblem{
+				Path:    "steps." + id + ".type",
+				Message: "sender cannot transfer chats",
+			})
+		}
+	}
+	if len(problems) > 0 {
+		return &ScenarioValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Start запускает сценарий в чате. Начальное состояние складывается из
+// значений по умолчанию переменных сценария и params.
+func (e *Engine) Start(ctx context.Context, chat Chat, params map[string]string) (*ScenarioSession, error) {
+	if chat.ID == "" {
+		return nil, ErrInvalidChatID
+	}
+
+	if old := e.lockSession(chat.ID); old != nil {
+		if !e.scenario.Settings.Restartable {
+			id := old.session.ID
+			e.unlock(old)
+			return nil, fmt.Errorf("%w: chat %s already runs session %s", ErrSessionState, chat.ID, id)
+		}
+		e.finish(old, SessionCancelled)
+		e.unlock(old)
+	}
+
+	state := map[string]string{}
+	for name, v := range e.scenario.Variables {
+		if v.Default != "" {
+			state[name] = v.Default
+		}
+	}
+	for name, value := range params {
+		state[name] = value
+	}
+	chat.Variables = maps.Clone(chat.Variables)
+
+	now := e.now()
+	s := &engineSession{session: ScenarioSession{
+		ID:        newSessionID(),
+		Scenario:  *e.scenario,
+		Chat:      chat,
+		Status:    SessionActive,
+		State:     state,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+
+	s.mu.Lock()
+	e.mu.Lock()
+	if _, busy := e.sessions[chat.ID]; busy {
+		e.mu.Unlock()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: chat %s already runs a session", ErrSessionState, chat.ID)
+	}
+	e.sessions[chat.ID] = s
+	e.mu.Unlock()
+
+	e.emit(s, EventScenarioStarted, "")
+	e.run(ctx, s, e.entry)
+	session := s.snapshot()
+	e.unlock(s)
+	return &session, nil
+}
+
+// Stop отменяет сессию в чате
+func (e *Engine) Stop(chatID string) bool {
+	s := e.lockSession(chatID)
+	if s == nil {
+		return false
+	}
+	e.finish(s, SessionCancelled)
+	e.unlock(s)
+	return true
+}
+
+// Session возвращает копию текущей сессии в чате. Если сессия в этот
+// момент выполняет шаг, вызов ждёт его окончания.
+func (e *Engine) Session(chatID string) (*ScenarioSession, bool) {
+	s := e.lockSession(chatID)
+	if s == nil {
+		return nil, false
+	}
+	session := s.snapshot()
+	e.unlock(s)
+	return &session, true
+}
+
+// lockSession находит сессию чата и захватывает её. Возвращает nil,
+// если сессии нет или она завершилась, пока ожидалась блокировка.
+func (e *Engine) lockSession(chatID string) *engineSession {
+	e.mu.Lock()
+	s := e.sessions[chatID]
+	e.mu.Unlock()
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return nil
+	}
+	return s
+}
+
+// unlock освобождает сессию и вызывает отложенные обработчики
+func (e *Engine) unlock(s *engineSession) {
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+// HandleUpdate передаёт событие сессии его чата. Сообщения и нажатия
+// кнопок считаются вводом для шага, ожидающего ответа. События чатов
+// без активной сессии игнорируются.
+func (e *Engine) HandleUpdate(ctx context.Context, event *WebhookEvent) error {
+	if event == nil {
+		return nil
+	}
+
+	var input string
+	switch event.Type {
+	case EventMessage:
+		if event.Message == nil {
+			return nil
+		}
+		input = event.Message.Text
+	case EventButton:
+		click, err := event.ButtonClick()
+		if err != nil {
+			return err
+		}
+		input = click.Value
+	default:
+		return nil
+	}
+
+	s := e.lockSession(event.Chat.ID)
+	if s == nil {
+		return nil
+	}
+	defer e.unlock(s)
+
+	// ввод, пришедший после истечения ожидания, не должен его опередить
+	e.checkTimeout(ctx, s, e.now())
+	if s.done || !s.waitingInput {
+		return nil
+	}
+	if event.Chat.Variables != nil {
+		s.session.Chat.Variables = maps.Clone(event.Chat.Variables)
+	}
+
+	step := e.step(s.session.CurrentStep.StepID)
+	payload, err := step.DecodePayload()
+	if err != nil {
+		e.fail(ctx, s, step, err, EventScenarioError)
+		return nil
+	}
+	if p, ok := payload.(*InputPayload); ok && p.Variable != "" {
+		s.session.State[p.Variable] = input
+	}
+
+	s.waitingInput = false
+	s.waitUntil = time.Time{}
+	result, _ := json.Marshal(StepResult{Input: input})
+	e.complete(s, result)
+	e.advance(ctx, s, step)
+	return nil
+}
+
+// CheckTimeouts завершает истёкшие ожидания ввода и задержки,
+// а также сессии, превысившие таймаут сценария. Сессии, которые
+// в этот момент выполняют шаг, проверяются при следующем вызове.
+func (e *Engine) CheckTimeouts(ctx context.Context) {
+	e.mu.Lock()
+	sessions := make([]*engineSession, 0, len(e.sessions))
+	for _, s := range e.sessions {
+		sessions = append(sessions, s)
+	}
+	e.mu.Unlock()
+
+	for _, s := range sessions {
+		if !s.mu.TryLock() {
+			continue
+		}
+		if !s.done {
+			e.checkTimeout(ctx, s, e.now())
+		}
+		e.unlock(s)
+	}
+}
+
+// RunTimeouts вызывает CheckTimeouts каждые interval, пока не отменён ctx.
+// Нужен, когда события подаются через HandleUpdate или HTTPHandler.
+func (e *Engine) RunTimeouts(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			e.CheckTimeouts(ctx)
+		}
+	}
+}
+
+func (e *Engine) checkTimeout(ctx context.Context, s *engineSession, now time.Time) {
+	if limit := e.scenario.Settings.Timeout; limit > 0 && now.Sub(s.session.CreatedAt) > time.Duration(limit)*time.Second {
+		e.logger.Info("Scenario session timed out", zap.String("sessionID", s.session.ID))
+		e.emit(s, EventScenarioTimeout, "scenario timed out")
+		e.finish(s, SessionFailed)
+		return
+	}
+	if s.waitUntil.IsZero() || now.Before(s.waitUntil) {
+		return
+	}
+
+	step := e.step(s.session.CurrentStep.StepID)
+	s.waitUntil = time.Time{}
+	if s.waitingInput {
+		s.waitingInput = false
+		e.fail(ctx, s, step, fmt.Errorf("step %s timed out", step.ID), EventScenarioTimeout)
+		return
+	}
+	e.complete(s, nil)
+	e.advance(ctx, s, step)
+}
+
+// ServePolling обрабатывает обновления из StartPolling и периодически
+// проверяет таймауты. Возвращает управление при закрытии канала или отмене ctx.
+func (e *Engine) ServePolling(ctx context.Context, updates <-chan PollingUpdate) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			e.CheckTimeouts(ctx)
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if update.Error != nil {
+				continue
+			}
+			if err := e.HandleUpdate(ctx, update.Event); err != nil {
+				e.logger.Warn("Scenario engine update failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// HTTPHandler обрабатывает событие, разобранное WebhookHandler.Handle.
+// Таймауты проверяются отдельно через RunTimeouts:
+//
+//	go engine.RunTimeouts(ctx, time.Second)
+//	http.Handle("/webhook", wh.Handle(engine.HTTPHandler()))
+func (e *Engine) HTTPHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		event, _ := r.Context().Value("webhookEvent").(*WebhookEvent)
+		if event == nil {
+			http.Error(w, "missing webhook event", http.StatusBadRequest)
+			return
+		}
+		if err := e.HandleUpdate(r.Context(), event); err != nil {
+			e.logger.Warn("Scenario engine update failed", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// run выполняет шаги, начиная со stepID, пока сессия не начнёт ждать
+// ввода или задержки либо не завершится. Вызывается под s.mu.
+func (e *Engine) run(ctx context.Context, s *engineSession, stepID string) {
+	for i := 0; i < maxEngineTransitions; i++ {
+		step := e.step(stepID)
+
+		now := e.now()
+		s.session.CurrentStep = StepExecution{StepID: stepID, StartedAt: now}
+		s.session.UpdatedAt = now
+		e.emit(s, EventStepEntered, "")
+
+		wait, err := e.execute(ctx, s, step)
+		if err != nil {
+			next, ok := e.errorTransition(s, step, err, EventScenarioError)
+			if !ok {
+				return
+			}
+			stepID = next
+			continue
+		}
+		if wait {
+			return
+		}
+
+		e.complete(s, nil)
+		next, done, err := e.nextStep(s, step)
+		if err != nil {
+			next, ok := e.errorTransition(s, step, err, EventScenarioError)
+			if !ok {
+				return
+			}
+			stepID = next
+			continue
+		}
+		if done {
+			e.finish(s, SessionCompleted)
+			return
+		}
+		stepID = next
+	}
+
+	e.logger.Error("Scenario engine transition limit reached", zap.String("sessionID", s.session.ID))
+	e.finish(s, SessionFailed)
+}
+
+func (e *Engine) step(id string) Step {
+	step := e.scenario.Steps[id]
+	if step.ID == "" {
+		step.ID = id
+	}
+	return step
+}
+
+// execute выполняет действие шага. wait означает, что шаг ждёт ввода или таймера.
+func (e *Engine) execute(ctx context.Context, s *engineSession, step Step) (bool, error) {
+	chatID := s.session.Chat.ID
+
+	payload, err := step.DecodePayload()
+	if err != nil {
+		return false, err
+	}
+
+	switch p := payload.(type) {
+	case *MessagePayload:
+		_, err := e.sender.SendMessage(ctx, chatID, renderMessage(p, e.sessionVars(s)))
+		return false, err
+
+	case *InputPayload:
+		if p.Text != "" {
+			if _, err := e.sender.SendMessage(ctx, chatID, TextMessage{Text: p.Text}); err != nil {
+				return false, err
+			}
+		}
+		s.waitingInput = true
+		if step.Timeout > 0 {
+			s.waitUntil = e.now().Add(time.Duration(step.Timeout) * time.Second)
+		}
+		return true, nil
+
+	case *DelayPayload:
+		if p.Seconds <= 0 {
+			return false, nil
+		}
+		s.waitUntil = e.now().Add(time.Duration(p.Seconds) * time.Second)
+		return true, nil
+
+	case *TransferPayload:
+		transferer, ok := e.sender.(chatTransferer)
+		if !ok {
+			return false, fmt.Errorf("sender cannot transfer chats")
+		}
+		return false, transferer.TransferToAgent(ctx, chatID, p.TransferOptions)
+
+	case *ConditionPayload:
+		return false, nil
+
+	default:
+		return false, fmt.Errorf("step type %q is not supported by the local engine", step.Type)
+	}
+}
+
+// advance переходит к следующему шагу после завершения ожидающего шага
+func (e *Engine) advance(ctx context.Context, s *engineSession, step Step) {
+	next, done, err := e.nextStep(s, step)
+	if err != nil {
+		e.fail(ctx, s, step, err, EventScenarioError)
+		return
+	}
+	if done {
+		e.finish(s, SessionCompleted)
+		return
+	}
+	e.run(ctx, s, next)
+}
+
+// nextStep выбирает первый переход, условие которого выполнено
+func (e *Engine) nextStep(s *engineSession, step Step) (string, bool, error) {
+	if len(step.NextSteps) == 0 {
+		return "", true, nil
+	}
+
+	vars := e.sessionVars(s)
+	for _, next := range step.NextSteps {
+		ok, err := e.evaluate(next.Condition, vars)
+		if err != nil {
+			return "", false, fmt.Errorf("condition %q: %w", next.Condition, err)
+		}
+		if ok {
+			return next.StepID, false, nil
+		}
+	}
+	return "", false, fmt.Errorf("no transition matched from step %s", step.ID)
+}
+
+// sessionVars объединяет переменные чата и состояние сессии для условий
+// и шаблонов сообщений; при совпадении имён приоритет у состояния
+func (e *Engine) sessionVars(s *engineSession) map[string]string {
+	vars := make(map[string]string, len(s.session.Chat.Variables)+len(s.session.State))
+	for name, value := range s.session.Chat.Variables {
+		vars[name] = value
+	}
+	for name, value := range s.session.State {
+		vars[name] = value
+	}
+	return vars
+}
+
+// fail обрабатывает ошибку шага вне цикла run
+func (e *Engine) fail(ctx context.Context, s *engineSession, step Step, err error, eventType string) {
+	if next, ok := e.errorTransition(s, step, err, eventType); ok {
+		e.run(ctx, s, next)
+	}
+}
+
+// errorTransition записывает ошибку шага, публикует одно событие eventType
+// (EventScenarioError или EventScenarioTimeout) и возвращает ErrorStep,
+// а если он не задан, завершает сессию с ошибкой
+func (e *Engine) errorTransition(s *engineSession, step Step, err error, eventType string) (string, bool) {
+	e.logger.Warn("Scenario step failed",
+		zap.String("sessionID", s.session.ID),
+		zap.String("stepID", step.ID),
+		zap.Error(err),
+	)
+
+	result, _ := json.Marshal(StepResult{Error: err.Error()})
+	e.emit(s, eventType, err.Error())
+	e.complete(s, result)
+
+	if step.ErrorStep == "" {
+		e.finish(s, SessionFailed)
+		return "", false
+	}
+	return step.ErrorStep, true
+}
+
+// complete отмечает текущий шаг выполненным и передаёт запись обработчику
+func (e *Engine) complete(s *engineSession, payload json.RawMessage) {
+	now := e.now()
+	s.session.CurrentStep.CompletedAt = now
+	s.session.CurrentStep.Payload = payload
+	s.session.UpdatedAt = now
+
+	if e.onStep != nil {
+		session, execution := s.snapshot(), s.session.CurrentStep
+		s.pending = append(s.pending, func() { e.onStep(session, execution) })
+	}
+	e.emit(s, EventStepCompleted, "")
+}
+
+func (e *Engine) finish(s *engineSession, status SessionStatus) {
+	s.session.Status = status
+	s.session.UpdatedAt = e.now()
+	s.waitingInput = false
+	s.waitUntil = time.Time{}
+	s.done = true
+
+	e.mu.Lock()
+	if e.sessions[s.session.Chat.ID] == s {
+		delete(e.sessions, s.session.Chat.ID)
+	}
+	e.mu.Unlock()
+	e.emit(s, EventScenarioEnded, "")
+
+	e.logger.Debug("Scenario session finished",
+		zap.String("sessionID", s.session.ID),
+		zap.String("status", string(status)),
+	)
+}
+
+// emit откладывает публикацию события сессии в поток до снятия s.mu
+func (e *Engine) emit(s *engineSession, eventType string, errText string) {
+	if e.events == nil {
+		return
+	}
+	event := ScenarioEvent{
+		Type:       eventType,
+		SessionID:  s.session.ID,
+		ScenarioID: e.scenario.ID,
+		ChatID:     s.session.Chat.ID,
+		Error:      errText,
+		Time:       e.now(),
+	}
+	switch eventType {
+	case EventStepEntered, EventStepCompleted, EventScenarioError, EventScenarioTimeout:
+		event.Step = s.session.CurrentStep
+	case EventScenarioEnded:
+		event.Status = s.session.Status
+	}
+	s.pending = append(s.pending, func() { e.events.Dispatch(event) })
+}
+
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// renderMessage подставляет переменные вместо {{name}} в тексте.
+// Значения экранируются под формат сообщения, поэтому ввод пользователя
+// не может добавить в текст разметку.
+func renderMessage(p *MessagePayload, vars map[string]string) OutgoingMessage {
+	return &RichMessage{
+		Text:    expandTemplate(p.Text, p.Format, vars),
+		Format:  p.Format,
+		Buttons: p.Buttons,
+	}
+}
+
+func expandTemplate(text string, format TextFormat, vars map[string]string) string {
+	if len(vars) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{{"+name+"}}", Escape(format, value))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
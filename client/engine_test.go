@@ -0,0 +1,544 @@
+package maxbotapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender запоминает отправленные тексты по чатам. Текст "boom" возвращает
+// ошибку, а для чата из block отправка ждёт закрытия канала.
+type fakeSender struct {
+	mu    sync.Mutex
+	sent  map[string][]string
+	block map[string]chan struct{}
+}
+
+func newFakeSender() *fakeSender {
+	return &fakeSender{sent: map[string][]string{}, block: map[string]chan struct{}{}}
+}
+
+func (f *fakeSender) SendMessage(ctx context.Context, chatID string, message OutgoingMessage) (*MessageResponse, error) {
+	f.mu.Lock()
+	wait := f.block[chatID]
+	f.mu.Unlock()
+	if wait != nil {
+		<-wait
+	}
+
+	var text string
+	switch m := message.(type) {
+	case *RichMessage:
+		text = m.Text
+	case TextMessage:
+		text = m.Text
+	}
+	if text == "boom" {
+		return nil, errors.New("send failed")
+	}
+
+	f.mu.Lock()
+	f.sent[chatID] = append(f.sent[chatID], text)
+	f.mu.Unlock()
+	return &MessageResponse{}, nil
+}
+
+func (f *fakeSender) texts(chatID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent[chatID]...)
+}
+
+// testClock управляемый источник времени для WithClock
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func textStep(text string, next ...NextStep) Step {
+	payload, _ := json.Marshal(MessagePayload{Text: text})
+	return Step{Type: StepMessage, Payload: payload, NextSteps: next}
+}
+
+func inputStep(text, variable string, next ...NextStep) Step {
+	payload, _ := json.Marshal(InputPayload{Text: text, Variable: variable})
+	return Step{Type: StepInput, Payload: payload, NextSteps: next}
+}
+
+// guarded задаёт шагу таймаут в секундах и шаг ошибки
+func guarded(s Step, timeout int, errorStep string) Step {
+	s.Timeout = timeout
+	s.ErrorStep = errorStep
+	return s
+}
+
+func messageEvent(chatID, text string) *WebhookEvent {
+	return &WebhookEvent{Type: EventMessage, Chat: Chat{ID: chatID}, Message: &Message{Text: text}}
+}
+
+// ageScenario: приветствие, вопрос о возрасте и ветвление по ответу
+func ageScenario() *Scenario {
+	return &Scenario{ID: "age", StartStep: "hello", Steps: map[string]Step{
+		"hello": textStep("hi", to("ask")),
+		"ask": inputStep("age?", "age",
+			NextStep{StepID: "adult", Condition: "age >= 18"},
+			to("minor"),
+		),
+		"adult": textStep("adult {{age}}"),
+		"minor": textStep("minor {{age}}"),
+	}}
+}
+
+func newTestEngine(t *testing.T, s *Scenario, sender MessageSender, opts ...EngineOption) *Engine {
+	t.Helper()
+	e, err := NewEngine(s, sender, opts...)
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	return e
+}
+
+func TestEngineTransitions(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantSent  []string
+		wantSteps []string
+	}{
+		{"30", []string{"hi", "age?", "adult 30"}, []string{"hello", "ask", "adult"}},
+		{"12", []string{"hi", "age?", "minor 12"}, []string{"hello", "ask", "minor"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			sender := newFakeSender()
+			var steps []string
+			e := newTestEngine(t, ageScenario(), sender, WithStepHandler(func(_ ScenarioSession, x StepExecution) {
+				steps = append(steps, x.StepID)
+			}))
+			ctx := context.Background()
+
+			session, err := e.Start(ctx, Chat{ID: "c1"}, nil)
+			if err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			if session.Status != SessionActive || session.CurrentStep.StepID != "ask" {
+				t.Fatalf("after Start: status %s, step %s", session.Status, session.CurrentStep.StepID)
+			}
+
+			if err := e.HandleUpdate(ctx, messageEvent("c1", tt.input)); err != nil {
+				t.Fatalf("HandleUpdate: %v", err)
+			}
+			if got := sender.texts("c1"); !reflect.DeepEqual(got, tt.wantSent) {
+				t.Errorf("sent %q, want %q", got, tt.wantSent)
+			}
+			if !reflect.DeepEqual(steps, tt.wantSteps) {
+				t.Errorf("completed steps %q, want %q", steps, tt.wantSteps)
+			}
+			if _, ok := e.Session("c1"); ok {
+				t.Error("session still active after last step")
+			}
+		})
+	}
+}
+
+func TestEngineStartRejectsRunningSession(t *testing.T) {
+	e := newTestEngine(t, ageScenario(), newFakeSender())
+	ctx := context.Background()
+
+	if _, err := e.Start(ctx, Chat{ID: "c1"}, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := e.Start(ctx, Chat{ID: "c1"}, nil); !errors.Is(err, ErrSessionState) {
+		t.Fatalf("second Start err = %v, want ErrSessionState", err)
+	}
+}
+
+func TestEngineTimeouts(t *testing.T) {
+	delay, _ := json.Marshal(DelayPayload{Seconds: 5})
+
+	tests := []struct {
+		name       string
+		scenario   *Scenario
+		advance    time.Duration
+		wantSent   []string
+		wantStatus SessionStatus // пусто, если сессия должна остаться активной
+	}{
+		{
+			name: "input timeout goes to error step",
+			scenario: &Scenario{StartStep: "ask", Steps: map[string]Step{
+				"ask":  guarded(inputStep("name?", "name", to("done")), 60, "late"),
+				"done": textStep("thanks"),
+				"late": textStep("too late"),
+			}},
+			advance:    61 * time.Second,
+			wantSent:   []string{"name?", "too late"},
+			wantStatus: SessionCompleted,
+		},
+		{
+			name: "input timeout without error step fails",
+			scenario: &Scenario{StartStep: "ask", Steps: map[string]Step{
+				"ask":  guarded(inputStep("name?", "name", to("done")), 60, ""),
+				"done": textStep("thanks"),
+			}},
+			advance:    61 * time.Second,
+			wantSent:   []string{"name?"},
+			wantStatus: SessionFailed,
+		},
+		{
+			name: "input not timed out yet",
+			scenario: &Scenario{StartStep: "ask", Steps: map[string]Step{
+				"ask":  guarded(inputStep("name?", "name", to("done")), 60, ""),
+				"done": textStep("thanks"),
+			}},
+			advance:  30 * time.Second,
+			wantSent: []string{"name?"},
+		},
+		{
+			name: "delay elapses",
+			scenario: &Scenario{StartStep: "wait", Steps: map[string]Step{
+				"wait": {Type: StepDelay, Payload: delay, NextSteps: []NextStep{to("done")}},
+				"done": textStep("later"),
+			}},
+			advance:    5*time.Second + time.Millisecond,
+			wantSent:   []string{"later"},
+			wantStatus: SessionCompleted,
+		},
+		{
+			name: "scenario timeout",
+			scenario: &Scenario{StartStep: "ask", Settings: ScenarioSettings{Timeout: 120}, Steps: map[string]Step{
+				"ask":  inputStep("name?", "name", to("done")),
+				"done": textStep("thanks"),
+			}},
+			advance:    121 * time.Second,
+			wantSent:   []string{"name?"},
+			wantStatus: SessionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			sender := newFakeSender()
+			stream := NewScenarioEventStream(32)
+			events, cancel := stream.Subscribe("")
+			defer cancel()
+			e := newTestEngine(t, tt.scenario, sender, WithClock(clock.Now), WithEventStream(stream))
+			ctx := context.Background()
+
+			if _, err := e.Start(ctx, Chat{ID: "c1"}, nil); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			clock.Advance(tt.advance)
+			e.CheckTimeouts(ctx)
+
+			if got := sender.texts("c1"); !reflect.DeepEqual(got, tt.wantSent) {
+				t.Errorf("sent %q, want %q", got, tt.wantSent)
+			}
+
+			_, active := e.Session("c1")
+			if tt.wantStatus == "" {
+				if !active {
+					t.Error("session ended, want active")
+				}
+				return
+			}
+			if active {
+				t.Fatalf("session still active, want %s", tt.wantStatus)
+			}
+			if got := endedStatus(events); got != tt.wantStatus {
+				t.Errorf("ended with %q, want %q", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// endedStatus возвращает статус из уже отправленного EventScenarioEnded
+func endedStatus(events <-chan ScenarioEvent) SessionStatus {
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == EventScenarioEnded {
+				return ev.Status
+			}
+		default:
+			return ""
+		}
+	}
+}
+
+func TestEngineErrorStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		scenario   *Scenario
+		wantSent   []string
+		wantResult string
+	}{
+		{
+			name: "send error goes to error step",
+			scenario: &Scenario{StartStep: "a", Steps: map[string]Step{
+				"a":       guarded(textStep("boom", to("b")), 0, "recover"),
+				"b":       textStep("unreachable on error"),
+				"recover": textStep("recovered"),
+			}},
+			wantSent:   []string{"recovered"},
+			wantResult: "send failed",
+		},
+		{
+			name: "no matching transition goes to error step",
+			scenario: &Scenario{StartStep: "a", Steps: map[string]Step{
+				"a":       guarded(textStep("hi", NextStep{StepID: "b", Condition: "never"}), 0, "recover"),
+				"b":       textStep("b"),
+				"recover": textStep("recovered"),
+			}},
+			wantSent:   []string{"hi", "recovered"},
+			wantResult: "no transition matched from step a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := newFakeSender()
+			var errText string
+			e := newTestEngine(t, tt.scenario, sender, WithStepHandler(func(_ ScenarioSession, x StepExecution) {
+				if r, err := x.Result(); err == nil && r.Error != "" {
+					errText = r.Error
+				}
+			}))
+
+			if _, err := e.Start(context.Background(), Chat{ID: "c1"}, nil); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			if got := sender.texts("c1"); !reflect.DeepEqual(got, tt.wantSent) {
+				t.Errorf("sent %q, want %q", got, tt.wantSent)
+			}
+			if errText != tt.wantResult {
+				t.Errorf("step error %q, want %q", errText, tt.wantResult)
+			}
+		})
+	}
+}
+
+func TestEngineSessionIsCopy(t *testing.T) {
+	e := newTestEngine(t, ageScenario(), newFakeSender())
+	ctx := context.Background()
+
+	session, err := e.Start(ctx, Chat{ID: "c1", Variables: Variables{"lang": "ru"}}, map[string]string{"src": "ad"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = session.State["age"]
+			_ = session.Chat.Variables["lang"]
+		}
+	}()
+	e.HandleUpdate(ctx, &WebhookEvent{Type: EventMessage, Chat: Chat{ID: "c1", Variables: Variables{"lang": "en"}}, Message: &Message{Text: "40"}})
+	wg.Wait()
+
+	if _, ok := session.State["age"]; ok {
+		t.Error("Start result State changed after HandleUpdate")
+	}
+	if session.Chat.Variables["lang"] != "ru" {
+		t.Error("Start result Chat.Variables changed after HandleUpdate")
+	}
+}
+
+func TestEngineHandlerMayCallEngine(t *testing.T) {
+	var e *Engine
+	var seen []bool
+	sender := newFakeSender()
+	e = newTestEngine(t, ageScenario(), sender, WithStepHandler(func(session ScenarioSession, x StepExecution) {
+		_, ok := e.Session(session.Chat.ID)
+		seen = append(seen, ok)
+		if x.StepID == "hello" {
+			e.Stop(session.Chat.ID)
+		}
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctx := context.Background()
+		e.Start(ctx, Chat{ID: "c1"}, nil)
+		e.HandleUpdate(ctx, messageEvent("c1", "30"))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("step handler calling Engine methods deadlocked")
+	}
+	if !reflect.DeepEqual(seen, []bool{true}) {
+		t.Errorf("Session visible from handler = %v, want [true]", seen)
+	}
+	if got := sender.texts("c1"); !reflect.DeepEqual(got, []string{"hi", "age?"}) {
+		t.Errorf("sent %q after Stop from handler", got)
+	}
+}
+
+func TestEngineSlowChatDoesNotBlockOthers(t *testing.T) {
+	sender := newFakeSender()
+	release := make(chan struct{})
+	sender.block["slow"] = release
+	e := newTestEngine(t, ageScenario(), sender)
+	ctx := context.Background()
+
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		e.Start(ctx, Chat{ID: "slow"}, nil)
+	}()
+	// ждём, пока медленная сессия займёт отправку
+	for i := 0; i < 100; i++ {
+		e.mu.Lock()
+		_, started := e.sessions["slow"]
+		e.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	fastDone := make(chan error, 1)
+	go func() {
+		_, err := e.Start(ctx, Chat{ID: "fast"}, nil)
+		e.CheckTimeouts(ctx)
+		fastDone <- err
+	}()
+
+	select {
+	case err := <-fastDone:
+		if err != nil {
+			t.Fatalf("Start fast: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow chat blocked another chat")
+	}
+
+	close(release)
+	<-slowDone
+	if got := sender.texts("slow"); !reflect.DeepEqual(got, []string{"hi", "age?"}) {
+		t.Errorf("slow chat sent %q", got)
+	}
+}
+
+func TestEngineTemplateVariables(t *testing.T) {
+	greeting, _ := json.Marshal(MessagePayload{Text: "*{{name}}* from {{city}}", Format: FormatMarkdown})
+	scenario := &Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask":   inputStep("name?", "name", to("greet")),
+		"greet": {Type: StepMessage, Payload: greeting},
+	}}
+	sender := newFakeSender()
+	e := newTestEngine(t, scenario, sender)
+	ctx := context.Background()
+
+	if _, err := e.Start(ctx, Chat{ID: "c1", Variables: Variables{"city": "St. Petersburg"}}, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := e.HandleUpdate(ctx, messageEvent("c1", "_Bob_")); err != nil {
+		t.Fatalf("HandleUpdate: %v", err)
+	}
+
+	want := []string{"name?", `*\_Bob\_* from St\. Petersburg`}
+	if got := sender.texts("c1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestEngineLateInputTimesOut(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	scenario := &Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask":  guarded(inputStep("name?", "name", to("done")), 60, "late"),
+		"done": textStep("thanks"),
+		"late": textStep("too late"),
+	}}
+	sender := newFakeSender()
+	e := newTestEngine(t, scenario, sender, WithClock(clock.Now))
+	ctx := context.Background()
+
+	if _, err := e.Start(ctx, Chat{ID: "c1"}, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	clock.Advance(61 * time.Second)
+	if err := e.HandleUpdate(ctx, messageEvent("c1", "Bob")); err != nil {
+		t.Fatalf("HandleUpdate: %v", err)
+	}
+
+	want := []string{"name?", "too late"}
+	if got := sender.texts("c1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sent %q, want %q", got, want)
+	}
+}
+
+func TestEngineRunTimeouts(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	scenario := &Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask":  guarded(inputStep("name?", "name", to("done")), 60, ""),
+		"done": textStep("thanks"),
+	}}
+	e := newTestEngine(t, scenario, newFakeSender(), WithClock(clock.Now))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if _, err := e.Start(ctx, Chat{ID: "c1"}, nil); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	clock.Advance(61 * time.Second)
+
+	done := make(chan error, 1)
+	go func() { done <- e.RunTimeouts(ctx, time.Millisecond) }()
+	deadline := time.After(time.Second)
+	for {
+		if _, active := e.Session("c1"); !active {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("RunTimeouts did not end the timed out session")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("RunTimeouts = %v, want context.Canceled", err)
+	}
+}
+
+func TestNewEngineRejectsUnsupportedSteps(t *testing.T) {
+	call, _ := json.Marshal(APICallPayload{URL: "https://example.com/{{id}}"})
+	scenario := &Scenario{StartStep: "call", Steps: map[string]Step{
+		"call":     {Type: StepAPICall, Payload: call, NextSteps: []NextStep{to("transfer")}},
+		"transfer": {Type: StepTransfer},
+	}}
+
+	_, err := NewEngine(scenario, newFakeSender())
+	var verr *ScenarioValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("NewEngine err = %v, want *ScenarioValidationError", err)
+	}
+	want := []ScenarioProblem{
+		{Path: "steps.call.type", Message: `step type "api_call" is not supported by the local engine`},
+		{Path: "steps.transfer.type", Message: "sender cannot transfer chats"},
+	}
+	if !reflect.DeepEqual(verr.Problems, want) {
+		t.Errorf("problems = %+v, want %+v", verr.Problems, want)
+	}
+}
@@ -3,14 +3,34 @@ package maxbotapi
 import "fmt"
 
 var (
-	ErrInvalidChatID    = fmt.Errorf("invalid chat ID")
-	ErrEmptyMessage     = fmt.Errorf("message cannot be empty")
-	ErrInvalidMessage   = fmt.Errorf("invalid message type")
-	ErrRequestFailed    = fmt.Errorf("request failed")
-	ErrUnauthorized     = fmt.Errorf("unauthorized")
-	ErrRateLimit        = fmt.Errorf("rate limit exceeded")
-	ErrWebhookFailed    = fmt.Errorf("webhook processing failed")
-	ErrSignatureInvalid = fmt.Errorf("invalid webhook signature")
+	ErrInvalidChatID     = fmt.Errorf("invalid chat ID")
+	ErrInvalidMessageID  = fmt.Errorf("invalid message ID")
+	ErrInvalidScenarioID = fmt.Errorf("invalid scenario ID")
+	ErrInvalidSessionID  = fmt.Errorf("invalid session ID")
+	ErrInvalidLimit      = fmt.Errorf("invalid limit")
+	ErrEmptyMessage      = fmt.Errorf("message cannot be empty")
+	ErrInvalidMessage    = fmt.Errorf("invalid message type")
+	ErrInvalidKeyboard   = fmt.Errorf("%w: invalid keyboard", ErrInvalidMessage) // ошибки клавиатуры являются и ErrInvalidMessage
+	ErrRequestFailed     = fmt.Errorf("request failed")
+	ErrUnauthorized      = fmt.Errorf("unauthorized")
+	ErrRateLimit         = fmt.Errorf("rate limit exceeded")
+	ErrWebhookFailed     = fmt.Errorf("webhook processing failed")
+	ErrSignatureInvalid  = fmt.Errorf("invalid webhook signature")
+	ErrEventType         = fmt.Errorf("unexpected event type")
+	ErrCallbackTooLarge  = fmt.Errorf("callback payload too large")
+	ErrCallbackInvalid   = fmt.Errorf("invalid callback payload")
+	ErrCallbackSigned    = fmt.Errorf("invalid callback signature")
+	ErrFileTooLarge      = fmt.Errorf("file too large")
+	ErrUnsupportedMedia  = fmt.Errorf("unsupported media type")
+	ErrVariableNotFound  = fmt.Errorf("variable not found")
+	ErrVersionConflict   = fmt.Errorf("version conflict")
+	ErrInvalidVariable   = fmt.Errorf("invalid variable")
+	ErrSessionState      = fmt.Errorf("invalid session state")
+	ErrNotInterruptible  = fmt.Errorf("scenario does not allow interruption")
+	ErrNotRestartable    = fmt.Errorf("scenario is not restartable")
+	ErrInvalidScenario   = fmt.Errorf("invalid scenario")
+	ErrInvalidCondition  = fmt.Errorf("invalid condition")
+	ErrUnknownStepType   = fmt.Errorf("unknown step type")
 )
 
 type APIError struct {
@@ -0,0 +1,54 @@
+package maxbotapi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Типы событий WebhookEvent.Type
+const (
+	EventMessage       = "message"
+	EventButton        = "button"
+	EventMessageStatus = "message_status" // квитанции о доставке и прочтении
+)
+
+// ButtonClick данные события нажатия на кнопку
+type ButtonClick struct {
+	ButtonID  string `json:"button_id"`
+	MessageID string `json:"message_id"`
+	Value     string `json:"value"`
+}
+
+// ButtonClick разбирает данные события нажатия на кнопку
+func (e *WebhookEvent) ButtonClick() (*ButtonClick, error) {
+	var click ButtonClick
+	if err := e.decodeData(EventButton, &click); err != nil {
+		return nil, err
+	}
+	return &click, nil
+}
+
+// DeliveryReceipt разбирает квитанцию о доставке или прочтении сообщения
+func (e *WebhookEvent) DeliveryReceipt() (*MessageStatus, error) {
+	var status MessageStatus
+	if err := e.decodeData(EventMessageStatus, &status); err != nil {
+		return nil, err
+	}
+	if status.ChatID == "" {
+		status.ChatID = e.Chat.ID
+	}
+	return &status, nil
+}
+
+func (e *WebhookEvent) decodeData(eventType string, v interface{}) error {
+	if e.Type != eventType {
+		return fmt.Errorf("%w: got %q, want %q", ErrEventType, e.Type, eventType)
+	}
+	if len(e.Data) == 0 {
+		return fmt.Errorf("event %s has no data", e.Type)
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("error unmarshaling %s event data: %w", e.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package maxbotapi
+
+import (
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// Ограничения клавиатуры на стороне платформы. Общее число кнопок
+// ограничено произведением MaxKeyboardRows и MaxButtonsPerRow.
+const (
+	MaxKeyboardRows      = 30
+	MaxButtonsPerRow     = 7
+	MaxButtonTitleLength = 64
+	MaxButtonValueLength = 1024
+)
+
+// CallbackButton кнопка, нажатие на которую приходит боту событием с payload
+func CallbackButton(title, payload string) Button {
+	return Button{Title: title, Type: ButtonCallback, Value: payload}
+}
+
+// URLButton кнопка-ссылка
+func URLButton(title, link string) Button {
+	return Button{Title: title, Type: ButtonURL, Value: link}
+}
+
+// RequestContactButton кнопка запроса контакта пользователя
+func RequestContactButton(title string) Button {
+	return Button{Title: title, Type: ButtonRequestContact}
+}
+
+// RequestLocationButton кнопка запроса геолокации пользователя
+func RequestLocationButton(title string) Button {
+	return Button{Title: title, Type: ButtonRequestLocation}
+}
+
+// OpenAppButton кнопка открытия мини-приложения
+func OpenAppButton(title, appID string) Button {
+	return Button{Title: title, Type: ButtonOpenApp, Value: appID}
+}
+
+// StartScenarioButton кнопка запуска сценария
+func StartScenarioButton(title, scenarioID string) Button {
+	return Button{Title: title, Type: ButtonStartScenario, Value: scenarioID}
+}
+
+// KeyboardBuilder раскладывает кнопки по рядам, переходя на новый ряд
+// при достижении заданного числа колонок.
+type KeyboardBuilder struct {
+	rows    [][]Button
+	columns int
+}
+
+// NewKeyboard создаёт построитель клавиатуры с MaxButtonsPerRow колонками
+func NewKeyboard() *KeyboardBuilder {
+	return &KeyboardBuilder{columns: MaxButtonsPerRow}
+}
+
+// Columns задаёт максимальное число кнопок в ряду
+func (k *KeyboardBuilder) Columns(n int) *KeyboardBuilder {
+	if n < 1 {
+		n = 1
+	}
+	if n > MaxButtonsPerRow {
+		n = MaxButtonsPerRow
+	}
+	k.columns = n
+	return k
+}
+
+// Add добавляет кнопки в текущий ряд, перенося лишние на следующие ряды
+func (k *KeyboardBuilder) Add(buttons ...Button) *KeyboardBuilder {
+	for _, b := range buttons {
+		last := len(k.rows) - 1
+		if last < 0 || len(k.rows[last]) >= k.columns {
+			k.rows = append(k.rows, nil)
+			last++
+		}
+		k.rows[last] = append(k.rows[last], b)
+	}
+	return k
+}
+
+// Row начинает новый ряд и добавляет в него кнопки
+func (k *KeyboardBuilder) Row(buttons ...Button) *KeyboardBuilder {
+	if len(buttons) == 0 {
+		return k
+	}
+	k.rows = append(k.rows, nil)
+	return k.Add(buttons...)
+}
+
+// Build проверяет ограничения и возвращает ряды кнопок
+func (k *KeyboardBuilder) Build() ([][]Button, error) {
+	if err := ValidateKeyboard(k.rows); err != nil {
+		return nil, err
+	}
+	return k.rows, nil
+}
+
+// ValidateKeyboard проверяет число рядов, кнопок в ряду и корректность каждой кнопки
+func ValidateKeyboard(rows [][]Button) error {
+	if len(rows) > MaxKeyboardRows {
+		return fmt.Errorf("%w: %d rows, max %d", ErrInvalidKeyboard, len(rows), MaxKeyboardRows)
+	}
+
+	for i, row := range rows {
+		if len(row) == 0 {
+			return fmt.Errorf("%w: row %d is empty", ErrInvalidKeyboard, i)
+		}
+		if len(row) > MaxButtonsPerRow {
+			return fmt.Errorf("%w: row %d has %d buttons, max %d", ErrInvalidKeyboard, i, len(row), MaxButtonsPerRow)
+		}
+		for _, b := range row {
+			if err := validateButton(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateButton(b Button) error {
+	if b.Title == "" {
+		return fmt.Errorf("%w: button %q has no title", ErrInvalidKeyboard, b.ID)
+	}
+	if n := utf8.RuneCountInString(b.Title); n > MaxButtonTitleLength {
+		return fmt.Errorf("%w: button title %q is %d characters, max %d", ErrInvalidKeyboard, b.Title, n, MaxButtonTitleLength)
+	}
+	if len(b.Value) > MaxButtonValueLength {
+		return fmt.Errorf("%w: button %q value is %d bytes, max %d", ErrInvalidKeyboard, b.Title, len(b.Value), MaxButtonValueLength)
+	}
+
+	switch b.Type {
+	case "", ButtonText, ButtonCallback, ButtonRequestContact, ButtonRequestLocation:
+	case ButtonURL:
+		u, err := url.Parse(b.Value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: button %q has invalid URL %q", ErrInvalidKeyboard, b.Title, b.Value)
+		}
+	case ButtonOpenApp, ButtonStartScenario:
+		if b.Value == "" {
+			return fmt.Errorf("%w: button %q of type %s requires a value", ErrInvalidKeyboard, b.Title, b.Type)
+		}
+	default:
+		return fmt.Errorf("%w: button %q has unknown type %q", ErrInvalidKeyboard, b.Title, b.Type)
+	}
+	return nil
+}
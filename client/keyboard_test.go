@@ -0,0 +1,68 @@
+package maxbotapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyboardErrorsAreInvalidMessage(t *testing.T) {
+	bad := Button{Type: ButtonCallback, Value: "x"} // без заголовка
+	tests := []struct {
+		name string
+		msg  OutgoingMessage
+	}{
+		{"buttons", ButtonsMessage{Text: "t", Buttons: []Button{bad}}},
+		{"keyboard", KeyboardMessage{Text: "t", Buttons: [][]Button{{bad}}}},
+		{"carousel", CarouselMessage{Carousel: []CarouselItem{{Title: "c", Buttons: []Button{bad}}}}},
+		{"rich", RichMessage{Text: "t", Buttons: [][]Button{{bad}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.msg.Validate()
+			if !errors.Is(err, ErrInvalidKeyboard) {
+				t.Fatalf("Validate() = %v, want ErrInvalidKeyboard", err)
+			}
+			if !errors.Is(err, ErrInvalidMessage) {
+				t.Fatalf("Validate() = %v, want ErrInvalidMessage", err)
+			}
+		})
+	}
+}
+
+func TestValidateKeyboardLimits(t *testing.T) {
+	row := func(n int) []Button {
+		buttons := make([]Button, n)
+		for i := range buttons {
+			buttons[i] = CallbackButton("b", "v")
+		}
+		return buttons
+	}
+	rows := func(n int) [][]Button {
+		keyboard := make([][]Button, n)
+		for i := range keyboard {
+			keyboard[i] = row(MaxButtonsPerRow)
+		}
+		return keyboard
+	}
+
+	tests := []struct {
+		name    string
+		rows    [][]Button
+		wantErr bool
+	}{
+		{"ok", [][]Button{row(3), row(MaxButtonsPerRow)}, false},
+		{"empty row", [][]Button{row(1), {}}, true},
+		{"wide row", [][]Button{row(MaxButtonsPerRow + 1)}, true},
+		{"full keyboard", rows(MaxKeyboardRows), false},
+		{"too many rows", rows(MaxKeyboardRows + 1), true},
+		{"bad url", [][]Button{{URLButton("site", "not a url")}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKeyboard(tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateKeyboard() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
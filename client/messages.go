@@ -0,0 +1,152 @@
+package maxbotapi
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// OutgoingMessage реализуется всеми типами исходящих сообщений.
+// Validate вызывается клиентом до отправки запроса, поэтому
+// некорректное сообщение не доходит до сервера.
+type OutgoingMessage interface {
+	Validate() error
+}
+
+var (
+	_ OutgoingMessage = (*TextMessage)(nil)
+	_ OutgoingMessage = (*ImageMessage)(nil)
+	_ OutgoingMessage = (*ButtonsMessage)(nil)
+	_ OutgoingMessage = (*KeyboardMessage)(nil)
+	_ OutgoingMessage = (*CarouselMessage)(nil)
+	_ OutgoingMessage = (*LocationMessage)(nil)
+	_ OutgoingMessage = (*ContactMessage)(nil)
+	_ OutgoingMessage = (*TemplateMessage)(nil)
+	_ OutgoingMessage = (*RichMessage)(nil)
+)
+
+// validateMessage проверяет сообщение перед отправкой. nil и типизированный
+// nil-указатель (например, (*TextMessage)(nil)) считаются пустым сообщением:
+// вызов Validate с методом на значении для них привёл бы к панике.
+func validateMessage(m OutgoingMessage) error {
+	if m == nil {
+		return ErrEmptyMessage
+	}
+	if v := reflect.ValueOf(m); v.Kind() == reflect.Pointer && v.IsNil() {
+		return ErrEmptyMessage
+	}
+	return m.Validate()
+}
+
+func invalidMessage(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidMessage, fmt.Sprintf(format, args...))
+}
+
+func (m TextMessage) Validate() error {
+	if strings.TrimSpace(m.Text) == "" {
+		return ErrEmptyMessage
+	}
+	return nil
+}
+
+func (m ImageMessage) Validate() error {
+	if m.ImageURL == "" && m.Token == "" {
+		return ErrEmptyMessage
+	}
+	return nil
+}
+
+func (m ButtonsMessage) Validate() error {
+	if strings.TrimSpace(m.Text) == "" {
+		return ErrEmptyMessage
+	}
+	if len(m.Buttons) == 0 {
+		return invalidMessage("buttons message has no buttons")
+	}
+	return validateButtons(m.Buttons)
+}
+
+func (m KeyboardMessage) Validate() error {
+	if strings.TrimSpace(m.Text) == "" {
+		return ErrEmptyMessage
+	}
+	if len(m.Buttons) == 0 {
+		return invalidMessage("keyboard has no buttons")
+	}
+	return ValidateKeyboard(m.Buttons)
+}
+
+func (m RichMessage) Validate() error {
+	if strings.TrimSpace(m.Text) == "" && len(m.Attachments) == 0 {
+		return ErrEmptyMessage
+	}
+	switch m.Format {
+	case FormatPlain, FormatMarkdown, FormatHTML:
+	default:
+		return invalidMessage("unknown text format %q", m.Format)
+	}
+	for i, a := range m.Attachments {
+		if a.Type == "" {
+			return invalidMessage("attachment %d has no type", i)
+		}
+		if a.Token == "" && a.URL == "" {
+			return invalidMessage("attachment %d has neither token nor URL", i)
+		}
+	}
+	return ValidateKeyboard(m.Buttons)
+}
+
+func (m CarouselMessage) Validate() error {
+	if len(m.Carousel) == 0 {
+		return ErrEmptyMessage
+	}
+	for i, item := range m.Carousel {
+		if item.Title == "" {
+			return invalidMessage("carousel item %d has no title", i)
+		}
+		if err := validateButtons(item.Buttons); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m LocationMessage) Validate() error {
+	if math.IsNaN(m.Latitude) || m.Latitude < -90 || m.Latitude > 90 {
+		return invalidMessage("latitude %v out of range", m.Latitude)
+	}
+	if math.IsNaN(m.Longitude) || m.Longitude < -180 || m.Longitude > 180 {
+		return invalidMessage("longitude %v out of range", m.Longitude)
+	}
+	return nil
+}
+
+func (m ContactMessage) Validate() error {
+	if m.PhoneNumber == "" && m.FirstName == "" {
+		return ErrEmptyMessage
+	}
+	if m.PhoneNumber == "" {
+		return invalidMessage("contact has no phone number")
+	}
+	if m.FirstName == "" {
+		return invalidMessage("contact has no first name")
+	}
+	return nil
+}
+
+func (m TemplateMessage) Validate() error {
+	if m.TemplateID == "" {
+		return invalidMessage("template ID is required")
+	}
+	return nil
+}
+
+func validateButtons(buttons []Button) error {
+	for _, b := range buttons {
+		if err := validateButton(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultPageSize = 50
+
+// MessagesQuery параметры выборки истории сообщений.
+// Limit размер страницы: 0 означает defaultPageSize (50), отрицательное
+// значение отклоняется с ErrInvalidLimit. Before и After ограничивают
+// выборку сообщениями старше или новее сообщения с указанным ID;
+// From и To задают диапазон дат.
+type MessagesQuery struct {
+	Limit  int
+	Before string
+	After  string
+	From   time.Time
+	To     time.Time
+}
+
+// normalize подставляет размер страницы по умолчанию и отклоняет
+// отрицательный Limit
+func (q *MessagesQuery) normalize() error {
+	if q.Limit < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidLimit, q.Limit)
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultPageSize
+	}
+	return nil
+}
+
+func (q MessagesQuery) values() url.Values {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(q.Limit))
+	if q.Before != "" {
+		params.Set("before", q.Before)
+	}
+	if q.After != "" {
+		params.Set("after", q.After)
+	}
+	if !q.From.IsZero() {
+		params.Set("from", q.From.Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		params.Set("to", q.To.Format(time.RFC3339))
+	}
+	return params
+}
+
+// ListMessages возвращает одну страницу истории сообщений чата
+func (c *Client) ListMessages(ctx context.Context, chatID string, query MessagesQuery) ([]Message, error) {
+	if err := query.normalize(); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/messages?%s", c.baseURL, apiVersion, chatID, query.values().Encode())
+
+	var messages []Message
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &messages)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// IterMessages лениво обходит историю чата постранично.
+// Если задан только After, обход идёт от старых сообщений к новым,
+// иначе от новых к старым. Обход прекращается при отмене ctx.
+//
+//	for msg, err := range client.IterMessages(ctx, chatID, MessagesQuery{}) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) IterMessages(ctx context.Context, chatID string, query MessagesQuery) iter.Seq2[Message, error] {
+	if err := query.normalize(); err != nil {
+		return func(yield func(Message, error) bool) { yield(Message{}, err) }
+	}
+	forward := query.After != "" && query.Before == ""
+
+	return paginate(ctx, func(cursor string) ([]Message, string, error) {
+		q := query
+		if cursor != "" {
+			if forward {
+				q.After = cursor
+			} else {
+				q.Before = cursor
+			}
+		}
+
+		page, err := c.ListMessages(ctx, chatID, q)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(page) < q.Limit {
+			return page, "", nil
+		}
+		return page, page[len(page)-1].ID, nil
+	})
+}
+
+// paginate превращает постраничную выборку в итератор.
+// fetch получает курсор предыдущей страницы (пустой для первой)
+// и возвращает элементы и курсор следующей; пустой курсор завершает обход.
+func paginate[T any](ctx context.Context, fetch func(cursor string) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		cursor := ""
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			items, next, err := fetch(cursor)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if next == "" || next == cursor || len(items) == 0 {
+				return
+			}
+			cursor = next
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package maxbotapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestGetMessagesLimit(t *testing.T) {
+	var limits []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		limits = append(limits, r.URL.Query().Get("limit"))
+		w.Write([]byte("[]"))
+	})
+	ctx := context.Background()
+
+	if _, err := c.GetMessages(ctx, "chat", 0); err != nil {
+		t.Fatalf("GetMessages(0): %v", err)
+	}
+	if _, err := c.GetMessages(ctx, "chat", 10); err != nil {
+		t.Fatalf("GetMessages(10): %v", err)
+	}
+	if _, err := c.GetMessages(ctx, "chat", -1); !errors.Is(err, ErrInvalidLimit) {
+		t.Fatalf("GetMessages(-1) = %v, want ErrInvalidLimit", err)
+	}
+	for _, err := range c.IterMessages(ctx, "chat", MessagesQuery{Limit: -5}) {
+		if !errors.Is(err, ErrInvalidLimit) {
+			t.Fatalf("IterMessages(-5) = %v, want ErrInvalidLimit", err)
+		}
+	}
+
+	want := []string{strconv.Itoa(defaultPageSize), "10"}
+	if fmt.Sprint(limits) != fmt.Sprint(want) {
+		t.Errorf("limits sent %q, want %q", limits, want)
+	}
+}
+
+func TestIterMessagesPages(t *testing.T) {
+	history := []Message{{ID: "5"}, {ID: "4"}, {ID: "3"}, {ID: "2"}, {ID: "1"}}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if before := r.URL.Query().Get("before"); before != "" {
+			for i, m := range history {
+				if m.ID == before {
+					start = i + 1
+				}
+			}
+		}
+		end := min(start+2, len(history))
+		json.NewEncoder(w).Encode(history[start:end])
+	})
+
+	var ids []string
+	for msg, err := range c.IterMessages(context.Background(), "chat", MessagesQuery{Limit: 2}) {
+		if err != nil {
+			t.Fatalf("IterMessages: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+	if fmt.Sprint(ids) != "[5 4 3 2 1]" {
+		t.Errorf("ids = %v", ids)
+	}
+}
@@ -26,7 +26,8 @@ func (c *Client) retryRequest(ctx context.Context, fn func() error) error {
 		lastErr = err
 
 		// Не повторяем для некоторых ошибок
-		if errors.Is(err, ErrInvalidChatID) || errors.Is(err, ErrUnauthorized) {
+		if errors.Is(err, ErrInvalidChatID) || errors.Is(err, ErrInvalidScenarioID) ||
+			errors.Is(err, ErrInvalidSessionID) || errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrVersionConflict) {
 			break
 		}
 
@@ -0,0 +1,311 @@
+package maxbotapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind вид изменения между версиями сценария
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// StepChange изменение шага. Для ChangeModified в Fields перечислены
+// изменившиеся поля в JSON-именовании: "type", "payload", "timeout" и т.д.
+type StepChange struct {
+	StepID string
+	Kind   ChangeKind
+	Fields []string
+}
+
+// TransitionChange добавленный или удалённый переход между шагами.
+// Error означает переход по ErrorStep.
+type TransitionChange struct {
+	From      string
+	To        string
+	Condition string
+	Error     bool
+	Kind      ChangeKind
+}
+
+// VariableChange изменение переменной сценария
+type VariableChange struct {
+	Name   string
+	Kind   ChangeKind
+	Fields []string
+}
+
+// ScenarioDiff структурные различия двух версий сценария
+type ScenarioDiff struct {
+	OldEntry    string
+	NewEntry    string
+	Steps       []StepChange
+	Transitions []TransitionChange
+	Variables   []VariableChange
+}
+
+// Empty сообщает, что версии структурно не отличаются
+func (d *ScenarioDiff) Empty() bool {
+	return d.OldEntry == d.NewEntry && len(d.Steps) == 0 && len(d.Transitions) == 0 && len(d.Variables) == 0
+}
+
+func (d *ScenarioDiff) String() string {
+	var sb strings.Builder
+	if d.OldEntry != d.NewEntry {
+		fmt.Fprintf(&sb, "~ entry step: %q -> %q\n", d.OldEntry, d.NewEntry)
+	}
+	for _, c := range d.Steps {
+		fmt.Fprintf(&sb, "%s step %s", changeMark(c.Kind), c.StepID)
+		if len(c.Fields) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(c.Fields, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	for _, c := range d.Transitions {
+		fmt.Fprintf(&sb, "%s transition %s -> %s", changeMark(c.Kind), c.From, c.To)
+		switch {
+		case c.Error:
+			sb.WriteString(" [error]")
+		case c.Condition != "":
+			fmt.Fprintf(&sb, " [%s]", c.Condition)
+		}
+		sb.WriteString("\n")
+	}
+	for _, c := range d.Variables {
+		fmt.Fprintf(&sb, "%s variable %s", changeMark(c.Kind), c.Name)
+		if len(c.Fields) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(c.Fields, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func changeMark(kind ChangeKind) string {
+	switch kind {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// DiffScenarios сравнивает две версии сценария. Переходы сравниваются как
+// множества (откуда, условие, куда), поэтому перестановка NextSteps
+// отражается только в полях изменённого шага.
+func DiffScenarios(old, updated *Scenario) *ScenarioDiff {
+	d := &ScenarioDiff{}
+	d.OldEntry, _ = old.EntryStep()
+	d.NewEntry, _ = updated.EntryStep()
+
+	for _, id := range unionKeys(old.Steps, updated.Steps) {
+		before, inOld := old.Steps[id]
+		after, inNew := updated.Steps[id]
+		switch {
+		case !inOld:
+			d.Steps = append(d.Steps, StepChange{StepID: id, Kind: ChangeAdded})
+		case !inNew:
+			d.Steps = append(d.Steps, StepChange{StepID: id, Kind: ChangeRemoved})
+		default:
+			if fields := stepFieldChanges(before, after); len(fields) > 0 {
+				d.Steps = append(d.Steps, StepChange{StepID: id, Kind: ChangeModified, Fields: fields})
+			}
+		}
+	}
+
+	oldEdges, newEdges := transitionSet(old), transitionSet(updated)
+	for _, t := range oldEdges {
+		if !containsTransition(newEdges, t) {
+			t.Kind = ChangeRemoved
+			d.Transitions = append(d.Transitions, t)
+		}
+	}
+	for _, t := range newEdges {
+		if !containsTransition(oldEdges, t) {
+			t.Kind = ChangeAdded
+			d.Transitions = append(d.Transitions, t)
+		}
+	}
+
+	for _, name := range unionKeys(old.Variables, updated.Variables) {
+		before, inOld := old.Variables[name]
+		after, inNew := updated.Variables[name]
+		switch {
+		case !inOld:
+			d.Variables = append(d.Variables, VariableChange{Name: name, Kind: ChangeAdded})
+		case !inNew:
+			d.Variables = append(d.Variables, VariableChange{Name: name, Kind: ChangeRemoved})
+		default:
+			if fields := variableFieldChanges(before, after); len(fields) > 0 {
+				d.Variables = append(d.Variables, VariableChange{Name: name, Kind: ChangeModified, Fields: fields})
+			}
+		}
+	}
+
+	return d
+}
+
+func stepFieldChanges(a, b Step) []string {
+	var fields []string
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if !jsonEqual(a.Payload, b.Payload) {
+		fields = append(fields, "payload")
+	}
+	if !equalNextSteps(a.NextSteps, b.NextSteps) {
+		fields = append(fields, "next_steps")
+	}
+	if a.Timeout != b.Timeout {
+		fields = append(fields, "timeout")
+	}
+	if a.ErrorStep != b.ErrorStep {
+		fields = append(fields, "error_step")
+	}
+	return fields
+}
+
+func variableFieldChanges(a, b Variable) []string {
+	var fields []string
+	if a.Type != b.Type {
+		fields = append(fields, "type")
+	}
+	if a.Required != b.Required {
+		fields = append(fields, "required")
+	}
+	if a.Default != b.Default {
+		fields = append(fields, "default")
+	}
+	if a.Description != b.Description {
+		fields = append(fields, "description")
+	}
+	return fields
+}
+
+func equalNextSteps(a, b []NextStep) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonEqual сравнивает JSON без учёта форматирования
+func jsonEqual(a, b []byte) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func transitionSet(s *Scenario) []TransitionChange {
+	var edges []TransitionChange
+	for _, id := range sortedStepIDs(s) {
+		step := s.Steps[id]
+		for _, next := range step.NextSteps {
+			edges = append(edges, TransitionChange{From: id, To: next.StepID, Condition: next.Condition})
+		}
+		if step.ErrorStep != "" {
+			edges = append(edges, TransitionChange{From: id, To: step.ErrorStep, Error: true})
+		}
+	}
+	return edges
+}
+
+func containsTransition(edges []TransitionChange, t TransitionChange) bool {
+	for _, e := range edges {
+		if e.From == t.From && e.To == t.To && e.Condition == t.Condition && e.Error == t.Error {
+			return true
+		}
+	}
+	return false
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SessionIncompatibility сессия, текущий шаг которой станет недействительным
+// после обновления сценария
+type SessionIncompatibility struct {
+	SessionID string
+	ChatID    string
+	StepID    string
+	Reason    string
+}
+
+func (i SessionIncompatibility) String() string {
+	return fmt.Sprintf("session %s: step %q %s", i.SessionID, i.StepID, i.Reason)
+}
+
+// CheckSessionCompatibility проверяет незавершённые сессии против новой
+// версии сценария. Сессия несовместима, если её текущий шаг удалён
+// или у него сменился тип.
+func CheckSessionCompatibility(sessions []ScenarioSession, updated *Scenario) []SessionIncompatibility {
+	var result []SessionIncompatibility
+	for _, session := range sessions {
+		if session.Status.Final() || session.CurrentStep.StepID == "" {
+			continue
+		}
+		stepID := session.CurrentStep.StepID
+
+		reason := ""
+		after, ok := updated.Steps[stepID]
+		if !ok {
+			reason = "was removed"
+		} else if before, ok := session.Scenario.Steps[stepID]; ok && before.Type != after.Type {
+			reason = fmt.Sprintf("changed type from %s to %s", before.Type, after.Type)
+		}
+
+		if reason != "" {
+			result = append(result, SessionIncompatibility{
+				SessionID: session.ID,
+				ChatID:    session.Chat.ID,
+				StepID:    stepID,
+				Reason:    reason,
+			})
+		}
+	}
+	return result
+}
+
+// MigrateSessions перезапускает незавершённые сессии, несовместимые с updated.
+// Сессия на сервере остаётся привязанной к своей версии сценария, и переход
+// JumpToStep на шаг новой версии её не переносит, поэтому несовместимая сессия
+// начинается заново через RestartSession. Сессии сценариев без Restartable
+// не трогаются и попадают в возвращаемую ошибку.
+func (c *Client) MigrateSessions(ctx context.Context, sessions []ScenarioSession, updated *Scenario) error {
+	var errs []error
+	for _, problem := range CheckSessionCompatibility(sessions, updated) {
+		if err := c.RestartSession(ctx, problem.SessionID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", problem, err))
+		}
+	}
+	return errors.Join(errs...)
+}
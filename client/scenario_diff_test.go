@@ -0,0 +1,204 @@
+package maxbotapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// diffBase исходная версия сценария для TestDiffScenarios
+func diffBase() *Scenario {
+	return &Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask":  guarded(inputStep("age?", "age", NextStep{StepID: "ok", Condition: "age >= 18"}, to("no")), 0, "fail"),
+		"ok":   textStep("welcome"),
+		"no":   textStep("sorry"),
+		"fail": textStep("error"),
+	}, Variables: map[string]Variable{"age": {Type: VariableNumber}}}
+}
+
+func TestDiffScenarios(t *testing.T) {
+	tests := []struct {
+		name            string
+		change          func(s *Scenario)
+		wantSteps       []StepChange
+		wantTransitions []TransitionChange
+		wantVariables   []VariableChange
+		wantEntry       string
+	}{
+		{
+			name:   "no changes",
+			change: func(s *Scenario) {},
+		},
+		{
+			name: "step added with transition",
+			change: func(s *Scenario) {
+				s.Steps["bye"] = textStep("bye")
+				s.Steps["ok"] = textStep("welcome", to("bye"))
+			},
+			wantSteps: []StepChange{
+				{StepID: "bye", Kind: ChangeAdded},
+				{StepID: "ok", Kind: ChangeModified, Fields: []string{"next_steps"}},
+			},
+			wantTransitions: []TransitionChange{{From: "ok", To: "bye", Kind: ChangeAdded}},
+		},
+		{
+			name: "step removed with its transitions",
+			change: func(s *Scenario) {
+				delete(s.Steps, "no")
+				s.Steps["ask"] = guarded(inputStep("age?", "age", NextStep{StepID: "ok", Condition: "age >= 18"}), 0, "fail")
+			},
+			wantSteps: []StepChange{
+				{StepID: "ask", Kind: ChangeModified, Fields: []string{"next_steps"}},
+				{StepID: "no", Kind: ChangeRemoved},
+			},
+			wantTransitions: []TransitionChange{{From: "ask", To: "no", Kind: ChangeRemoved}},
+		},
+		{
+			name: "condition changed",
+			change: func(s *Scenario) {
+				s.Steps["ask"] = guarded(inputStep("age?", "age", NextStep{StepID: "ok", Condition: "age >= 21"}, to("no")), 0, "fail")
+			},
+			wantSteps: []StepChange{{StepID: "ask", Kind: ChangeModified, Fields: []string{"next_steps"}}},
+			wantTransitions: []TransitionChange{
+				{From: "ask", To: "ok", Condition: "age >= 18", Kind: ChangeRemoved},
+				{From: "ask", To: "ok", Condition: "age >= 21", Kind: ChangeAdded},
+			},
+		},
+		{
+			name: "error step and timeout changed",
+			change: func(s *Scenario) {
+				s.Steps["ask"] = guarded(s.Steps["ask"], 30, "no")
+			},
+			wantSteps: []StepChange{{StepID: "ask", Kind: ChangeModified, Fields: []string{"timeout", "error_step"}}},
+			wantTransitions: []TransitionChange{
+				{From: "ask", To: "fail", Error: true, Kind: ChangeRemoved},
+				{From: "ask", To: "no", Error: true, Kind: ChangeAdded},
+			},
+		},
+		{
+			name: "type and payload changed",
+			change: func(s *Scenario) {
+				s.Steps["no"] = inputStep("why?", "reason")
+			},
+			wantSteps: []StepChange{{StepID: "no", Kind: ChangeModified, Fields: []string{"type", "payload"}}},
+		},
+		{
+			name: "reordered payload keys are equal",
+			change: func(s *Scenario) {
+				step := s.Steps["ok"]
+				step.Payload = json.RawMessage(`{ "text" : "welcome" }`)
+				s.Steps["ok"] = step
+			},
+		},
+		{
+			name: "variables and entry",
+			change: func(s *Scenario) {
+				s.StartStep = "ok"
+				s.Variables["age"] = Variable{Type: VariableNumber, Required: true}
+				s.Variables["name"] = Variable{}
+			},
+			wantEntry: "ok",
+			wantVariables: []VariableChange{
+				{Name: "age", Kind: ChangeModified, Fields: []string{"required"}},
+				{Name: "name", Kind: ChangeAdded},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := diffBase()
+			tt.change(updated)
+			d := DiffScenarios(diffBase(), updated)
+
+			if !reflect.DeepEqual(d.Steps, tt.wantSteps) {
+				t.Errorf("Steps = %+v, want %+v", d.Steps, tt.wantSteps)
+			}
+			if !reflect.DeepEqual(d.Transitions, tt.wantTransitions) {
+				t.Errorf("Transitions = %+v, want %+v", d.Transitions, tt.wantTransitions)
+			}
+			if !reflect.DeepEqual(d.Variables, tt.wantVariables) {
+				t.Errorf("Variables = %+v, want %+v", d.Variables, tt.wantVariables)
+			}
+			wantEntry := tt.wantEntry
+			if wantEntry == "" {
+				wantEntry = "ask"
+			}
+			if d.NewEntry != wantEntry {
+				t.Errorf("NewEntry = %q, want %q", d.NewEntry, wantEntry)
+			}
+			empty := tt.wantSteps == nil && tt.wantTransitions == nil && tt.wantVariables == nil && tt.wantEntry == ""
+			if d.Empty() != empty {
+				t.Errorf("Empty() = %v, want %v:\n%s", d.Empty(), empty, d)
+			}
+		})
+	}
+}
+
+func TestCheckSessionCompatibility(t *testing.T) {
+	updated := diffBase()
+	delete(updated.Steps, "no")
+	updated.Steps["ok"] = inputStep("name?", "name")
+
+	session := func(id, step string, status SessionStatus) ScenarioSession {
+		return ScenarioSession{ID: id, Chat: Chat{ID: "chat-" + id}, Scenario: *diffBase(), Status: status, CurrentStep: StepExecution{StepID: step}}
+	}
+	sessions := []ScenarioSession{
+		session("unchanged", "ask", SessionActive),
+		session("removed", "no", SessionActive),
+		session("retyped", "ok", SessionPaused),
+		session("finished", "no", SessionCompleted),
+		session("not started", "", SessionActive),
+	}
+
+	got := CheckSessionCompatibility(sessions, updated)
+	want := []SessionIncompatibility{
+		{SessionID: "removed", ChatID: "chat-removed", StepID: "no", Reason: "was removed"},
+		{SessionID: "retyped", ChatID: "chat-retyped", StepID: "ok", Reason: "changed type from message to input"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckSessionCompatibility = %+v, want %+v", got, want)
+	}
+}
+
+func TestMigrateSessionsRestartsIncompatible(t *testing.T) {
+	updated := diffBase()
+	delete(updated.Steps, "no")
+
+	sessions := map[string]ScenarioSession{}
+	for _, s := range []ScenarioSession{
+		{ID: "keep", Status: SessionActive, CurrentStep: StepExecution{StepID: "ask"}},
+		{ID: "restart", Status: SessionActive, CurrentStep: StepExecution{StepID: "no"}},
+		{ID: "locked", Status: SessionActive, CurrentStep: StepExecution{StepID: "no"}},
+	} {
+		s.Scenario = *diffBase()
+		s.Scenario.Settings.Restartable = s.ID != "locked"
+		sessions[s.ID] = s
+	}
+
+	var actions []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET":
+			json.NewEncoder(w).Encode(sessions[path.Base(r.URL.Path)])
+		case r.Method == "POST":
+			actions = append(actions, strings.TrimPrefix(r.URL.Path, "/api/"+apiVersion+"/sessions/"))
+			w.Write([]byte("{}"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	list := []ScenarioSession{sessions["keep"], sessions["restart"], sessions["locked"]}
+	err := c.MigrateSessions(context.Background(), list, updated)
+	if !errors.Is(err, ErrNotRestartable) || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("err = %v, want ErrNotRestartable for session locked", err)
+	}
+	if !reflect.DeepEqual(actions, []string{"restart/restart"}) {
+		t.Errorf("actions = %q, want [restart/restart]", actions)
+	}
+}
@@ -0,0 +1,283 @@
+package maxbotapi
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Типы событий выполнения сценариев (WebhookEvent.Type)
+const (
+	EventScenarioStarted = "scenario_started"
+	EventStepEntered     = "scenario_step_entered"
+	EventStepCompleted   = "scenario_step_completed"
+	EventScenarioTimeout = "scenario_timeout"
+	EventScenarioError   = "scenario_error"
+	EventScenarioEnded   = "scenario_ended"
+)
+
+// IsScenarioEvent сообщает, относится ли тип события к выполнению сценариев
+func IsScenarioEvent(eventType string) bool {
+	switch eventType {
+	case EventScenarioStarted, EventStepEntered, EventStepCompleted,
+		EventScenarioTimeout, EventScenarioError, EventScenarioEnded:
+		return true
+	}
+	return false
+}
+
+// ScenarioEvent событие выполнения сценария. Step заполнен для событий
+// шагов, Status для EventScenarioEnded, Error для EventScenarioError
+// и EventScenarioTimeout.
+type ScenarioEvent struct {
+	Type       string        `json:"type"`
+	SessionID  string        `json:"session_id"`
+	ScenarioID string        `json:"scenario_id"`
+	ChatID     string        `json:"chat_id"`
+	Step       StepExecution `json:"step"`
+	Status     SessionStatus `json:"status,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Time       time.Time     `json:"time"`
+}
+
+// ScenarioEvent разбирает данные события выполнения сценария
+func (e *WebhookEvent) ScenarioEvent() (*ScenarioEvent, error) {
+	if !IsScenarioEvent(e.Type) {
+		return nil, fmt.Errorf("%w: %q is not a scenario event", ErrEventType, e.Type)
+	}
+
+	var event ScenarioEvent
+	if err := e.decodeData(e.Type, &event); err != nil {
+		return nil, err
+	}
+	event.Type = e.Type
+	if event.ChatID == "" {
+		event.ChatID = e.Chat.ID
+	}
+	if event.Time.IsZero() {
+		event.Time = e.CreatedAt
+	}
+	return &event, nil
+}
+
+// ScenarioEventStream раздаёт события сценариев подписчикам.
+// Событие доставляется без блокировки: если буфер подписчика заполнен,
+// событие для него теряется. Поток помнит последние события каждой сессии
+// и повторяет их подписчику, пришедшему позже. История хранится для
+// activeSessionsLimit незавершённых и endedSessionsLimit завершённых
+// сессий; при переполнении вытесняются самые старые.
+//
+// Подписка на сессию, история которой уже вытеснена, ничего не повторяет
+// и не закрывается сама, даже если сессия давно завершилась: ожидание
+// такой подписки нужно ограничивать контекстом или таймаутом.
+type ScenarioEventStream struct {
+	buffer int
+
+	mu      sync.Mutex
+	subs    map[*scenarioSubscription]struct{}
+	history map[string][]ScenarioEvent
+	active  []string        // незавершённые сессии в порядке первого события
+	ended   []string        // завершённые сессии в порядке завершения
+	isEnded map[string]bool // сессии из ended
+}
+
+const (
+	// sessionHistoryLimit сколько последних событий сессии хранится для повтора
+	sessionHistoryLimit = 256
+	// activeSessionsLimit сколько незавершённых сессий хранится, если их
+	// EventScenarioEnded так и не пришёл
+	activeSessionsLimit = 1024
+	// endedSessionsLimit сколько завершённых сессий хранится для поздних подписчиков
+	endedSessionsLimit = 1024
+)
+
+type scenarioSubscription struct {
+	sessionID string // пустая строка означает все сессии
+	events    chan ScenarioEvent
+	steps     chan StepExecution
+}
+
+func (s *scenarioSubscription) close() {
+	if s.events != nil {
+		close(s.events)
+	}
+	if s.steps != nil {
+		close(s.steps)
+	}
+}
+
+// NewScenarioEventStream создаёт поток событий с буфером buffer
+// на каждого подписчика
+func NewScenarioEventStream(buffer int) *ScenarioEventStream {
+	if buffer < 0 {
+		buffer = 0
+	}
+	return &ScenarioEventStream{
+		buffer:  buffer,
+		subs:    map[*scenarioSubscription]struct{}{},
+		history: map[string][]ScenarioEvent{},
+		isEnded: map[string]bool{},
+	}
+}
+
+// Subscribe подписывается на все события сессии sessionID, а при пустом
+// sessionID на события всех сессий. Подписчик сессии сначала получает
+// уже произошедшие события. Канал сессии закрывается после
+// EventScenarioEnded, в том числе сразу, если сессия уже завершилась;
+// cancel отменяет подписку и закрывает канал.
+func (s *ScenarioEventStream) Subscribe(sessionID string) (<-chan ScenarioEvent, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.history[sessionID]
+	sub := &scenarioSubscription{sessionID: sessionID, events: make(chan ScenarioEvent, max(s.buffer, len(history)))}
+	for _, event := range history {
+		sub.events <- event
+	}
+	return sub.events, s.addLocked(sub, history)
+}
+
+// Steps подписывается на записи о выполненных шагах сессии sessionID,
+// начиная с уже выполненных. Канал закрывается после завершения сессии
+// или вызова cancel.
+func (s *ScenarioEventStream) Steps(sessionID string) (<-chan StepExecution, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.history[sessionID]
+	var steps []StepExecution
+	for _, event := range history {
+		if event.Type == EventStepCompleted {
+			steps = append(steps, event.Step)
+		}
+	}
+	sub := &scenarioSubscription{sessionID: sessionID, steps: make(chan StepExecution, max(s.buffer, len(steps)))}
+	for _, step := range steps {
+		sub.steps <- step
+	}
+	return sub.steps, s.addLocked(sub, history)
+}
+
+// addLocked регистрирует подписку; если по истории сессия уже
+// завершилась, каналы закрываются сразу. Вызывается под s.mu.
+func (s *ScenarioEventStream) addLocked(sub *scenarioSubscription, history []ScenarioEvent) func() {
+	for _, event := range history {
+		if event.Type == EventScenarioEnded {
+			sub.close()
+			return func() {}
+		}
+	}
+	s.subs[sub] = struct{}{}
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[sub]; ok {
+			delete(s.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// record добавляет событие в историю сессии. Повторный EventScenarioEnded
+// не записывается. Вызывается под s.mu.
+func (s *ScenarioEventStream) record(event ScenarioEvent) {
+	id := event.SessionID
+	if id == "" || (event.Type == EventScenarioEnded && s.isEnded[id]) {
+		return
+	}
+
+	history, known := s.history[id]
+	if !known && !s.isEnded[id] {
+		s.active = append(s.active, id)
+		if len(s.active) > activeSessionsLimit {
+			delete(s.history, s.active[0])
+			s.active = s.active[1:]
+		}
+	}
+	s.history[id] = trimHistory(append(history, event))
+
+	if event.Type == EventScenarioEnded {
+		if i := slices.Index(s.active, id); i >= 0 {
+			s.active = slices.Delete(s.active, i, i+1)
+		}
+		s.isEnded[id] = true
+		s.ended = append(s.ended, id)
+		if len(s.ended) > endedSessionsLimit {
+			delete(s.history, s.ended[0])
+			delete(s.isEnded, s.ended[0])
+			s.ended = s.ended[1:]
+		}
+	}
+}
+
+// trimHistory оставляет последние sessionHistoryLimit событий,
+// сохраняя EventScenarioStarted в начале истории
+func trimHistory(history []ScenarioEvent) []ScenarioEvent {
+	if len(history) <= sessionHistoryLimit {
+		return history
+	}
+	if history[0].Type == EventScenarioStarted {
+		tail := history[len(history)-sessionHistoryLimit+1:]
+		return append(history[:1:1], tail...)
+	}
+	return history[len(history)-sessionHistoryLimit:]
+}
+
+// Dispatch передаёт событие подписчикам его сессии и подписчикам всех сессий
+func (s *ScenarioEventStream) Dispatch(event ScenarioEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(event)
+
+	for sub := range s.subs {
+		if sub.sessionID != "" && sub.sessionID != event.SessionID {
+			continue
+		}
+
+		if sub.events != nil {
+			select {
+			case sub.events <- event:
+			default:
+			}
+		}
+		if sub.steps != nil && event.Type == EventStepCompleted {
+			select {
+			case sub.steps <- event.Step:
+			default:
+			}
+		}
+
+		if sub.sessionID != "" && event.Type == EventScenarioEnded {
+			delete(s.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// HandleEvent передаёт в поток события сценариев из вебхука или
+// long polling; прочие события пропускаются
+func (s *ScenarioEventStream) HandleEvent(event *WebhookEvent) error {
+	if event == nil || !IsScenarioEvent(event.Type) {
+		return nil
+	}
+	scenarioEvent, err := event.ScenarioEvent()
+	if err != nil {
+		return err
+	}
+	s.Dispatch(*scenarioEvent)
+	return nil
+}
+
+// Close закрывает каналы всех подписчиков
+func (s *ScenarioEventStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		sub.close()
+	}
+}
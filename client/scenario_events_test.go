@@ -0,0 +1,164 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func collectEvents(t *testing.T, events <-chan ScenarioEvent) []string {
+	t.Helper()
+	var types []string
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return types
+			}
+			types = append(types, event.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("channel not closed, got %q", types)
+		}
+	}
+}
+
+func TestScenarioEventStreamLateSubscriber(t *testing.T) {
+	stream := NewScenarioEventStream(1)
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: "s1"})
+	stream.Dispatch(ScenarioEvent{Type: EventStepCompleted, SessionID: "s1", Step: StepExecution{StepID: "a"}})
+	stream.Dispatch(ScenarioEvent{Type: EventStepCompleted, SessionID: "s1", Step: StepExecution{StepID: "b"}})
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: "s1", Status: SessionCompleted})
+
+	events, cancel := stream.Subscribe("s1")
+	defer cancel()
+	want := []string{EventScenarioStarted, EventStepCompleted, EventStepCompleted, EventScenarioEnded}
+	if got := collectEvents(t, events); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+
+	steps, cancelSteps := stream.Steps("s1")
+	defer cancelSteps()
+	var ids []string
+	for step := range steps {
+		ids = append(ids, step.StepID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b"}) {
+		t.Errorf("replayed steps %q, want [a b]", ids)
+	}
+}
+
+func TestScenarioEventStreamReplayThenLive(t *testing.T) {
+	stream := NewScenarioEventStream(4)
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: "s1"})
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: "s2"})
+
+	events, cancel := stream.Subscribe("s1")
+	defer cancel()
+	stream.Dispatch(ScenarioEvent{Type: EventStepEntered, SessionID: "s1"})
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: "s1"})
+
+	want := []string{EventScenarioStarted, EventStepEntered, EventScenarioEnded}
+	if got := collectEvents(t, events); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestScenarioEventStreamForgetsOldSessions(t *testing.T) {
+	stream := NewScenarioEventStream(1)
+	for i := 0; i <= endedSessionsLimit; i++ {
+		stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: fmt.Sprintf("s%d", i)})
+	}
+	if len(stream.history) != endedSessionsLimit {
+		t.Errorf("%d sessions kept, want %d", len(stream.history), endedSessionsLimit)
+	}
+}
+
+func TestScenarioEventStreamForgetsStaleActiveSessions(t *testing.T) {
+	stream := NewScenarioEventStream(1)
+	for i := 0; i <= activeSessionsLimit; i++ {
+		stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: fmt.Sprintf("s%d", i)})
+	}
+	if len(stream.history) != activeSessionsLimit {
+		t.Errorf("%d sessions kept, want %d", len(stream.history), activeSessionsLimit)
+	}
+	if _, ok := stream.history["s0"]; ok {
+		t.Error("oldest active session kept")
+	}
+
+	// завершённая сессия больше не считается активной
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: "s1"})
+	if len(stream.active) != activeSessionsLimit-1 {
+		t.Errorf("%d active sessions, want %d", len(stream.active), activeSessionsLimit-1)
+	}
+}
+
+func TestScenarioEventStreamDedupesEnded(t *testing.T) {
+	stream := NewScenarioEventStream(1)
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: "s1"})
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: "s1"})
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: "s1"})
+
+	if len(stream.ended) != 1 {
+		t.Errorf("ended = %q, want one entry", stream.ended)
+	}
+	events, cancel := stream.Subscribe("s1")
+	defer cancel()
+	want := []string{EventScenarioStarted, EventScenarioEnded}
+	if got := collectEvents(t, events); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed %q, want %q", got, want)
+	}
+}
+
+func TestScenarioEventStreamKeepsStartedOnTrim(t *testing.T) {
+	stream := NewScenarioEventStream(1)
+	stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: "s1"})
+	for i := 0; i < 2*sessionHistoryLimit; i++ {
+		stream.Dispatch(ScenarioEvent{Type: EventStepEntered, SessionID: "s1", Step: StepExecution{StepID: fmt.Sprint(i)}})
+	}
+
+	history := stream.history["s1"]
+	if len(history) != sessionHistoryLimit {
+		t.Fatalf("history has %d events, want %d", len(history), sessionHistoryLimit)
+	}
+	if history[0].Type != EventScenarioStarted {
+		t.Errorf("first event %q, want %q", history[0].Type, EventScenarioStarted)
+	}
+	if last := history[len(history)-1].Step.StepID; last != fmt.Sprint(2*sessionHistoryLimit-1) {
+		t.Errorf("last step %q, want the newest", last)
+	}
+}
+
+func TestEngineInputTimeoutEmitsOneEvent(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	stream := NewScenarioEventStream(32)
+	scenario := &Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask":  guarded(inputStep("name?", "name", to("done")), 60, ""),
+		"done": textStep("thanks"),
+	}}
+	e := newTestEngine(t, scenario, newFakeSender(), WithClock(clock.Now), WithEventStream(stream))
+	ctx := context.Background()
+
+	session, err := e.Start(ctx, Chat{ID: "c1"}, nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	clock.Advance(61 * time.Second)
+	e.CheckTimeouts(ctx)
+
+	events, cancel := stream.Subscribe(session.ID)
+	defer cancel()
+	var timeouts, errs int
+	for _, typ := range collectEvents(t, events) {
+		switch typ {
+		case EventScenarioTimeout:
+			timeouts++
+		case EventScenarioError:
+			errs++
+		}
+	}
+	if timeouts != 1 || errs != 0 {
+		t.Errorf("timeout events %d, error events %d; want 1 and 0", timeouts, errs)
+	}
+}
@@ -0,0 +1,117 @@
+package maxbotapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScenarioDOT возвращает граф шагов сценария в формате Graphviz DOT.
+// Переходы подписаны условиями, переходы на ErrorStep показаны пунктиром.
+func ScenarioDOT(s *Scenario) string {
+	var sb strings.Builder
+	entry, _ := s.EntryStep()
+
+	fmt.Fprintf(&sb, "digraph %s {\n", dotQuote(scenarioTitle(s)))
+	sb.WriteString("  rankdir=TB;\n")
+	sb.WriteString("  node [shape=box, style=rounded];\n")
+
+	for _, id := range sortedStepIDs(s) {
+		step := s.Steps[id]
+		attrs := fmt.Sprintf("label=%s", dotQuote(id+"\n"+step.Type))
+		if id == entry {
+			attrs += ", penwidth=2"
+		}
+		if len(step.NextSteps) == 0 {
+			attrs += ", peripheries=2"
+		}
+		fmt.Fprintf(&sb, "  %s [%s];\n", dotQuote(id), attrs)
+	}
+
+	for _, id := range sortedStepIDs(s) {
+		step := s.Steps[id]
+		for _, next := range step.NextSteps {
+			if next.Condition != "" {
+				fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(id), dotQuote(next.StepID), dotQuote(next.Condition))
+			} else {
+				fmt.Fprintf(&sb, "  %s -> %s;\n", dotQuote(id), dotQuote(next.StepID))
+			}
+		}
+		if step.ErrorStep != "" {
+			fmt.Fprintf(&sb, "  %s -> %s [style=dashed, color=red, label=\"error\"];\n", dotQuote(id), dotQuote(step.ErrorStep))
+		}
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ScenarioMermaid возвращает граф шагов сценария в формате Mermaid flowchart
+func ScenarioMermaid(s *Scenario) string {
+	var sb strings.Builder
+	entry, _ := s.EntryStep()
+
+	ids := map[string]string{}
+	for i, id := range sortedStepIDs(s) {
+		ids[id] = fmt.Sprintf("s%d", i)
+	}
+	nodeID := func(stepID string) string {
+		if n, ok := ids[stepID]; ok {
+			return n
+		}
+		return "missing_" + strings.Map(func(r rune) rune {
+			if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+				return r
+			}
+			return '_'
+		}, stepID)
+	}
+
+	sb.WriteString("flowchart TD\n")
+	for _, id := range sortedStepIDs(s) {
+		step := s.Steps[id]
+		label := mermaidQuote(id + "<br/>" + step.Type)
+		if len(step.NextSteps) == 0 {
+			fmt.Fprintf(&sb, "  %s([%s])\n", nodeID(id), label)
+		} else {
+			fmt.Fprintf(&sb, "  %s[%s]\n", nodeID(id), label)
+		}
+	}
+
+	for _, id := range sortedStepIDs(s) {
+		step := s.Steps[id]
+		for _, next := range step.NextSteps {
+			if next.Condition != "" {
+				fmt.Fprintf(&sb, "  %s -->|%s| %s\n", nodeID(id), mermaidQuote(next.Condition), nodeID(next.StepID))
+			} else {
+				fmt.Fprintf(&sb, "  %s --> %s\n", nodeID(id), nodeID(next.StepID))
+			}
+		}
+		if step.ErrorStep != "" {
+			fmt.Fprintf(&sb, "  %s -.->|error| %s\n", nodeID(id), nodeID(step.ErrorStep))
+		}
+	}
+
+	if entry != "" {
+		fmt.Fprintf(&sb, "  style %s stroke-width:3px\n", nodeID(entry))
+	}
+	return sb.String()
+}
+
+func scenarioTitle(s *Scenario) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	if s.ID != "" {
+		return s.ID
+	}
+	return "scenario"
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidQuote экранирует текст для подписи в кавычках Mermaid
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "|", "#124;").Replace(s) + `"`
+}
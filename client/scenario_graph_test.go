@@ -0,0 +1,50 @@
+package maxbotapi
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// readScenarioFile читает сценарий из testdata
+func readScenarioFile(t *testing.T, name string) (*ScenarioDocument, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ReadScenarioYAML(data)
+	if err != nil {
+		t.Fatalf("ReadScenarioYAML(%s): %v", name, err)
+	}
+	return doc, data
+}
+
+// checkGolden сравнивает got с testdata/name; с -update перезаписывает файл
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s mismatch (rerun with -update to accept)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestScenarioGraphGolden(t *testing.T) {
+	doc, _ := readScenarioFile(t, "survey.yaml")
+
+	checkGolden(t, "survey.dot", []byte(ScenarioDOT(doc.Scenario)))
+	checkGolden(t, "survey.mmd", []byte(ScenarioMermaid(doc.Scenario)))
+}
@@ -0,0 +1,158 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const defaultSessionPollInterval = 2 * time.Second
+
+// RunOptions настройки запуска сценария через RunScenario
+type RunOptions struct {
+	// Wait ждать завершения сессии
+	Wait bool
+	// Timeout ограничивает ожидание; 0 означает ожидание до отмены ctx
+	Timeout time.Duration
+	// PollInterval период опроса GetSession, по умолчанию 2 секунды
+	PollInterval time.Duration
+	// Events поток событий сценариев; если задан, завершение сессии
+	// определяется по EventScenarioEnded без ожидания очередного опроса
+	Events *ScenarioEventStream
+}
+
+// ScenarioResult итог запуска сценария
+type ScenarioResult struct {
+	SessionID string
+	Status    SessionStatus
+	State     map[string]string
+}
+
+// RunScenario проверяет параметры по переменным сценария и запускает его в чате.
+// params может быть Variables, map[string]string или структурой (указателем
+// на структуру) с тегами `var`; нулевые поля структуры передаются, если у них
+// нет опции omitempty. Прочие типы отклоняются с ErrInvalidVariable.
+// С opts.Wait ждёт завершения сессии и возвращает её итоговое состояние;
+// при истечении ожидания возвращает последнее известное состояние вместе с ошибкой.
+func (c *Client) RunScenario(ctx context.Context, chatID string, scenarioID string, params interface{}, opts RunOptions) (*ScenarioResult, error) {
+	scenario, err := c.GetScenario(ctx, scenarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	var vars Variables
+	switch p := params.(type) {
+	case nil:
+		vars, err = ValidateVariables(nil, scenario.Variables)
+	case Variables:
+		vars, err = ValidateVariables(p, scenario.Variables)
+	case map[string]string:
+		vars, err = ValidateVariables(Variables(p), scenario.Variables)
+	default:
+		if !isStructParams(p) {
+			return nil, fmt.Errorf("%w: params must be Variables, map[string]string or a struct, got %T", ErrInvalidVariable, params)
+		}
+		vars, err = VariablesFromStruct(p, scenario.Variables)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	started, err := c.StartScenario(ctx, chatID, scenarioID, vars.Params())
+	if err != nil {
+		return nil, err
+	}
+
+	// События, пришедшие до подписки, поток повторяет из истории сессии,
+	// поэтому завершение коротких сценариев не теряется
+	var events <-chan ScenarioEvent
+	if opts.Wait && opts.Events != nil && started.SessionID != "" {
+		var cancel func()
+		events, cancel = opts.Events.Subscribe(started.SessionID)
+		defer cancel()
+	}
+
+	result := &ScenarioResult{
+		SessionID: started.SessionID,
+		Status:    SessionStatus(started.Status),
+		State:     vars,
+	}
+	if !opts.Wait {
+		return result, nil
+	}
+
+	session, err := c.waitSession(ctx, started.SessionID, opts, events)
+	if session != nil {
+		result.Status = session.Status
+		result.State = session.State
+	}
+	return result, err
+}
+
+// isStructParams сообщает, что params структура или ненулевой указатель на неё
+func isStructParams(params interface{}) bool {
+	rv := reflect.ValueOf(params)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv.Kind() == reflect.Struct
+}
+
+// WaitSession опрашивает сессию, пока она не завершится или не истечёт ctx.
+// Вместе с ошибкой возвращается последнее полученное состояние сессии.
+func (c *Client) WaitSession(ctx context.Context, sessionID string, pollInterval time.Duration) (*ScenarioSession, error) {
+	return c.waitSession(ctx, sessionID, RunOptions{PollInterval: pollInterval}, nil)
+}
+
+func (c *Client) waitSession(ctx context.Context, sessionID string, opts RunOptions, events <-chan ScenarioEvent) (*ScenarioSession, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = defaultSessionPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last *ScenarioSession
+	for {
+		session, err := c.GetSession(ctx, sessionID)
+		if err != nil {
+			return last, fmt.Errorf("waiting for session %s: %w", sessionID, err)
+		}
+		last = session
+		if session.Status.Final() {
+			return session, nil
+		}
+
+		if err := waitSessionPoll(ctx, ticker, &events, sessionID); err != nil {
+			return last, fmt.Errorf("waiting for session %s: %w", sessionID, err)
+		}
+	}
+}
+
+// waitSessionPoll ждёт следующего опроса: тика таймера или события
+// о завершении сессии
+func waitSessionPoll(ctx context.Context, ticker *time.Ticker, events *<-chan ScenarioEvent, sessionID string) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			return nil
+		case event, ok := <-*events:
+			if !ok {
+				*events = nil
+				continue
+			}
+			if event.SessionID == sessionID && event.Type == EventScenarioEnded {
+				return nil
+			}
+		}
+	}
+}
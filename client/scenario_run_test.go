@@ -0,0 +1,206 @@
+package maxbotapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runServer отвечает на запросы RunScenario: отдаёт сценарий, запускает
+// сессию s1 и возвращает её статусы из statuses по очереди, повторяя последний
+type runServer struct {
+	mu       sync.Mutex
+	statuses []SessionStatus
+	starts   []map[string]interface{}
+	polls    int
+	onStart  func()
+}
+
+func newRunServer(t *testing.T, statuses ...SessionStatus) (*Client, *runServer) {
+	t.Helper()
+	srv := &runServer{statuses: statuses}
+	scenario := Scenario{ID: "sc", Variables: map[string]Variable{
+		"age":  {Type: VariableInteger, Required: true},
+		"lang": {Type: VariableString, Default: "ru"},
+	}}
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		switch {
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/scenarios/sc"):
+			json.NewEncoder(w).Encode(scenario)
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/chats/c1/scenarios/sc/start"):
+			var params map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&params)
+			srv.starts = append(srv.starts, params)
+			if srv.onStart != nil {
+				srv.onStart()
+			}
+			json.NewEncoder(w).Encode(ScenarioResponse{SessionID: "s1", Status: string(SessionActive)})
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/sessions/s1"):
+			status := srv.statuses[min(srv.polls, len(srv.statuses)-1)]
+			srv.polls++
+			json.NewEncoder(w).Encode(ScenarioSession{ID: "s1", Status: status, State: map[string]string{"step": string(status)}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	return c, srv
+}
+
+func (s *runServer) counts() (starts, polls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.starts), s.polls
+}
+
+func TestRunScenarioRejectsInvalidParams(t *testing.T) {
+	type profile struct {
+		Age int `var:"age,omitempty"`
+	}
+
+	tests := []struct {
+		name   string
+		params interface{}
+		errMsg string
+	}{
+		{"missing required", map[string]string{"lang": "en"}, "age is required"},
+		{"wrong type", Variables{"age": "ten"}, `"ten" is not a valid integer`},
+		{"omitted struct field", profile{}, "age is required"},
+		{"unsupported map", map[string]int{"age": 30}, "got map[string]int"},
+		{"nil struct pointer", (*profile)(nil), "got *maxbotapi.profile"},
+		{"scalar", 30, "got int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := newRunServer(t, SessionActive)
+
+			_, err := c.RunScenario(context.Background(), "c1", "sc", tt.params, RunOptions{})
+			if !errors.Is(err, ErrInvalidVariable) || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("err = %v, want ErrInvalidVariable with %q", err, tt.errMsg)
+			}
+			if starts, _ := srv.counts(); starts != 0 {
+				t.Errorf("scenario started %d times after invalid params", starts)
+			}
+		})
+	}
+}
+
+func TestRunScenarioNoWait(t *testing.T) {
+	c, srv := newRunServer(t, SessionCompleted)
+
+	result, err := c.RunScenario(context.Background(), "c1", "sc", &struct {
+		Age int `var:"age"`
+	}{Age: 30}, RunOptions{})
+	if err != nil {
+		t.Fatalf("RunScenario: %v", err)
+	}
+	if result.SessionID != "s1" || result.Status != SessionActive {
+		t.Errorf("result = %+v, want active session s1", result)
+	}
+	if result.State["age"] != "30" || result.State["lang"] != "ru" {
+		t.Errorf("State = %v, want age and default lang", result.State)
+	}
+	if starts, polls := srv.counts(); starts != 1 || polls != 0 {
+		t.Errorf("starts %d, polls %d; want 1 and 0", starts, polls)
+	}
+	if got := srv.starts[0]; got["age"] != "30" || got["lang"] != "ru" {
+		t.Errorf("start params = %v", got)
+	}
+}
+
+func TestRunScenarioWaitsForFinalState(t *testing.T) {
+	c, srv := newRunServer(t, SessionActive, SessionPaused, SessionCompleted)
+
+	result, err := c.RunScenario(context.Background(), "c1", "sc", Variables{"age": "30"}, RunOptions{
+		Wait:         true,
+		PollInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("RunScenario: %v", err)
+	}
+	if result.Status != SessionCompleted || result.State["step"] != string(SessionCompleted) {
+		t.Errorf("result = %+v, want completed session state", result)
+	}
+	if _, polls := srv.counts(); polls != 3 {
+		t.Errorf("polls = %d, want 3", polls)
+	}
+}
+
+func TestRunScenarioTimeoutReturnsLastState(t *testing.T) {
+	c, _ := newRunServer(t, SessionActive, SessionPaused)
+
+	result, err := c.RunScenario(context.Background(), "c1", "sc", Variables{"age": "30"}, RunOptions{
+		Wait:         true,
+		Timeout:      50 * time.Millisecond,
+		PollInterval: time.Millisecond,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if result == nil || result.SessionID != "s1" || result.Status != SessionPaused {
+		t.Errorf("result = %+v, want last polled paused session", result)
+	}
+}
+
+func TestRunScenarioWakesOnEndedEvent(t *testing.T) {
+	c, srv := newRunServer(t, SessionActive, SessionCompleted)
+	stream := NewScenarioEventStream(4)
+	// сценарий завершается раньше, чем RunScenario успевает подписаться
+	srv.onStart = func() {
+		stream.Dispatch(ScenarioEvent{Type: EventScenarioStarted, SessionID: "s1"})
+		stream.Dispatch(ScenarioEvent{Type: EventScenarioEnded, SessionID: "s1", Status: SessionCompleted})
+	}
+
+	start := time.Now()
+	result, err := c.RunScenario(context.Background(), "c1", "sc", Variables{"age": "30"}, RunOptions{
+		Wait:         true,
+		Timeout:      5 * time.Second,
+		PollInterval: time.Hour,
+		Events:       stream,
+	})
+	if err != nil {
+		t.Fatalf("RunScenario: %v", err)
+	}
+	if result.Status != SessionCompleted {
+		t.Errorf("Status = %s, want completed", result.Status)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("RunScenario waited %v for the next poll", elapsed)
+	}
+}
+
+func TestWaitSession(t *testing.T) {
+	c, srv := newRunServer(t, SessionActive, SessionFailed)
+
+	session, err := c.WaitSession(context.Background(), "s1", time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitSession: %v", err)
+	}
+	if session.Status != SessionFailed {
+		t.Errorf("Status = %s, want failed", session.Status)
+	}
+
+	srv.mu.Lock()
+	srv.statuses, srv.polls = []SessionStatus{SessionActive}, 0
+	srv.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	session, err = c.WaitSession(ctx, "s1", time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if session == nil || session.Status != SessionActive {
+		t.Errorf("session = %+v, want last active state", session)
+	}
+
+	if _, err := c.WaitSession(context.Background(), "", time.Millisecond); !errors.Is(err, ErrInvalidSessionID) {
+		t.Errorf("WaitSession(\"\") = %v, want ErrInvalidSessionID", err)
+	}
+}
@@ -0,0 +1,286 @@
+package maxbotapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ScenarioProblem ошибка в определении сценария.
+// Path указывает место в JSON, например "steps.greet.next_steps[1].step_id".
+type ScenarioProblem struct {
+	Path    string
+	Message string
+}
+
+func (p ScenarioProblem) String() string {
+	return p.Path + ": " + p.Message
+}
+
+// ScenarioValidationError содержит все найденные в сценарии ошибки
+type ScenarioValidationError struct {
+	Problems []ScenarioProblem
+}
+
+func (e *ScenarioValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.String()
+	}
+	return fmt.Sprintf("%s: %d problem(s):\n%s", ErrInvalidScenario, len(e.Problems), strings.Join(lines, "\n"))
+}
+
+func (e *ScenarioValidationError) Unwrap() error {
+	return ErrInvalidScenario
+}
+
+// ValidateScenario проверяет сценарий локально: ссылки на шаги, достижимость,
+// циклы без выхода, таймауты, типы и нагрузку шагов.
+// Возвращает *ScenarioValidationError со всеми найденными проблемами или nil.
+func ValidateScenario(s *Scenario) error {
+	v := &scenarioValidator{scenario: s}
+	v.validate()
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ScenarioValidationError{Problems: v.problems}
+}
+
+// EntryStep возвращает ID первого шага сценария: StartStep, если он задан,
+// иначе единственный шаг без входящих переходов
+func (s *Scenario) EntryStep() (string, error) {
+	if s.StartStep != "" {
+		if _, ok := s.Steps[s.StartStep]; !ok {
+			return "", fmt.Errorf("start step %q does not exist", s.StartStep)
+		}
+		return s.StartStep, nil
+	}
+
+	incoming := map[string]bool{}
+	for id, step := range s.Steps {
+		for _, next := range step.NextSteps {
+			if next.StepID != id {
+				incoming[next.StepID] = true
+			}
+		}
+		if step.ErrorStep != "" && step.ErrorStep != id {
+			incoming[step.ErrorStep] = true
+		}
+	}
+
+	var roots []string
+	for _, id := range sortedStepIDs(s) {
+		if !incoming[id] {
+			roots = append(roots, id)
+		}
+	}
+
+	switch len(roots) {
+	case 1:
+		return roots[0], nil
+	case 0:
+		return "", fmt.Errorf("no step without incoming transitions; set start_step")
+	default:
+		return "", fmt.Errorf("several steps without incoming transitions (%s); set start_step", strings.Join(roots, ", "))
+	}
+}
+
+type scenarioValidator struct {
+	scenario *Scenario
+	problems []ScenarioProblem
+}
+
+func (v *scenarioValidator) addf(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, ScenarioProblem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *scenarioValidator) validate() {
+	s := v.scenario
+	if len(s.Steps) == 0 {
+		v.addf("steps", "scenario has no steps")
+		return
+	}
+	if s.Settings.Timeout < 0 {
+		v.addf("settings.timeout", "must not be negative, got %d", s.Settings.Timeout)
+	}
+
+	for _, id := range sortedStepIDs(s) {
+		v.validateStep(id, s.Steps[id])
+	}
+
+	entry, err := s.EntryStep()
+	if err != nil {
+		v.addf("start_step", "%v", err)
+		return
+	}
+	v.checkReachability(entry)
+	v.checkCycles()
+}
+
+func (v *scenarioValidator) validateStep(id string, step Step) {
+	path := "steps." + id
+
+	if step.ID != "" && step.ID != id {
+		v.addf(path+".id", "%q does not match step key", step.ID)
+	}
+	if step.Type == "" {
+		v.addf(path+".type", "is required")
+	} else if _, ok := stepPayloadFactory(step.Type); !ok {
+		v.addf(path+".type", "unknown step type %q", step.Type)
+	} else if payload, err := DecodeStepPayload(step.Type, step.Payload); err != nil {
+		v.addf(path+".payload", "%v", err)
+	} else if err := payload.Validate(); err != nil {
+		v.addf(path+".payload", "%v", err)
+	}
+
+	if step.Timeout < 0 {
+		v.addf(path+".timeout", "must not be negative, got %d", step.Timeout)
+	}
+	if limit := v.scenario.Settings.Timeout; limit > 0 && step.Timeout > limit {
+		v.addf(path+".timeout", "%d exceeds scenario timeout %d", step.Timeout, limit)
+	}
+
+	for i, next := range step.NextSteps {
+		nextPath := fmt.Sprintf("%s.next_steps[%d].step_id", path, i)
+		if next.StepID == "" {
+			v.addf(nextPath, "is required")
+		} else if _, ok := v.scenario.Steps[next.StepID]; !ok {
+			v.addf(nextPath, "refers to unknown step %q", next.StepID)
+		}
+		if _, err := ParseCondition(next.Condition); err != nil {
+			v.addf(fmt.Sprintf("%s.next_steps[%d].condition", path, i), "%v", err)
+		}
+	}
+
+	if step.ErrorStep != "" {
+		if _, ok := v.scenario.Steps[step.ErrorStep]; !ok {
+			v.addf(path+".error_step", "refers to unknown step %q", step.ErrorStep)
+		}
+	}
+}
+
+func (v *scenarioValidator) checkReachability(entry string) {
+	reached := map[string]bool{entry: true}
+	queue := []string{entry}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range stepEdges(v.scenario.Steps[id]) {
+			if _, ok := v.scenario.Steps[next]; ok && !reached[next] {
+				reached[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	for _, id := range sortedStepIDs(v.scenario) {
+		if !reached[id] {
+			v.addf("steps."+id, "unreachable from entry step %q", entry)
+		}
+	}
+}
+
+// checkCycles ищет сильно связные компоненты (алгоритм Тарьяна) и сообщает
+// о циклах, из которых нет перехода наружу
+func (v *scenarioValidator) checkCycles() {
+	steps := v.scenario.Steps
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var components [][]string
+	counter := 0
+
+	var connect func(id string)
+	connect = func(id string) {
+		index[id] = counter
+		low[id] = counter
+		counter++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range stepEdges(steps[id]) {
+			if _, ok := steps[next]; !ok {
+				continue
+			}
+			if _, visited := index[next]; !visited {
+				connect(next)
+				low[id] = min(low[id], low[next])
+			} else if onStack[next] {
+				low[id] = min(low[id], index[next])
+			}
+		}
+
+		if low[id] == index[id] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+
+	for _, id := range sortedStepIDs(v.scenario) {
+		if _, visited := index[id]; !visited {
+			connect(id)
+		}
+	}
+
+	for _, component := range components {
+		members := map[string]bool{}
+		for _, id := range component {
+			members[id] = true
+		}
+
+		cyclic := len(component) > 1
+		hasExit := false
+		for _, id := range component {
+			step := steps[id]
+			if len(step.NextSteps) == 0 {
+				hasExit = true
+			}
+			for _, next := range stepEdges(step) {
+				if next == id {
+					cyclic = true
+				}
+				if !members[next] {
+					hasExit = true
+				}
+			}
+		}
+
+		if cyclic && !hasExit {
+			sort.Strings(component)
+			v.addf("steps."+component[0], "steps %s form a cycle without exit", strings.Join(component, ", "))
+		}
+	}
+}
+
+// stepEdges возвращает все шаги, на которые может перейти step
+func stepEdges(step Step) []string {
+	edges := make([]string, 0, len(step.NextSteps)+1)
+	for _, next := range step.NextSteps {
+		if next.StepID != "" {
+			edges = append(edges, next.StepID)
+		}
+	}
+	if step.ErrorStep != "" {
+		edges = append(edges, step.ErrorStep)
+	}
+	return edges
+}
+
+func sortedStepIDs(s *Scenario) []string {
+	ids := make([]string, 0, len(s.Steps))
+	for id := range s.Steps {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
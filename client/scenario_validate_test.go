@@ -0,0 +1,150 @@
+package maxbotapi
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func msgStep(next ...NextStep) Step {
+	return Step{Type: StepMessage, Payload: json.RawMessage(`{"text":"hi"}`), NextSteps: next}
+}
+
+func to(id string) NextStep { return NextStep{StepID: id} }
+
+func TestValidateScenarioProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario Scenario
+		want     []string // пути проблем в порядке вывода
+	}{
+		{
+			name: "valid linear",
+			scenario: Scenario{Steps: map[string]Step{
+				"a": msgStep(to("b")),
+				"b": msgStep(),
+			}},
+		},
+		{
+			name:     "no steps",
+			scenario: Scenario{},
+			want:     []string{"steps"},
+		},
+		{
+			name: "unknown next step",
+			scenario: Scenario{Steps: map[string]Step{
+				"a": msgStep(to("missing")),
+			}},
+			want: []string{"steps.a.next_steps[0].step_id"},
+		},
+		{
+			name: "unknown type and bad payload",
+			scenario: Scenario{StartStep: "a", Steps: map[string]Step{
+				"a": {Type: "teleport", NextSteps: []NextStep{to("b")}},
+				"b": {Type: StepInput, Payload: json.RawMessage(`{}`)},
+			}},
+			want: []string{"steps.a.type", "steps.b.payload"},
+		},
+		{
+			name: "bad condition",
+			scenario: Scenario{Steps: map[string]Step{
+				"a": msgStep(NextStep{StepID: "b", Condition: "age >"}),
+				"b": msgStep(),
+			}},
+			want: []string{"steps.a.next_steps[0].condition"},
+		},
+		{
+			name: "step timeout over scenario timeout",
+			scenario: Scenario{Settings: ScenarioSettings{Timeout: 10}, Steps: map[string]Step{
+				"a": {Type: StepInput, Payload: json.RawMessage(`{"variable":"x"}`), Timeout: 60},
+			}},
+			want: []string{"steps.a.timeout"},
+		},
+		{
+			name: "unreachable step",
+			scenario: Scenario{StartStep: "a", Steps: map[string]Step{
+				"a": msgStep(),
+				"b": msgStep(),
+			}},
+			want: []string{"steps.b"},
+		},
+		{
+			name: "cycle without exit",
+			scenario: Scenario{StartStep: "a", Steps: map[string]Step{
+				"a": msgStep(to("b")),
+				"b": msgStep(to("c")),
+				"c": msgStep(to("b")),
+			}},
+			want: []string{"steps.b"},
+		},
+		{
+			name: "self loop without exit",
+			scenario: Scenario{StartStep: "a", Steps: map[string]Step{
+				"a": msgStep(to("a")),
+			}},
+			want: []string{"steps.a"},
+		},
+		{
+			name: "cycle with conditional exit",
+			scenario: Scenario{StartStep: "a", Steps: map[string]Step{
+				"a":    msgStep(NextStep{StepID: "done", Condition: "ok"}, to("a")),
+				"done": msgStep(),
+			}},
+		},
+		{
+			name: "ambiguous entry",
+			scenario: Scenario{Steps: map[string]Step{
+				"a": msgStep(),
+				"b": msgStep(),
+			}},
+			want: []string{"start_step"},
+		},
+		{
+			name: "missing start step",
+			scenario: Scenario{StartStep: "zzz", Steps: map[string]Step{
+				"a": msgStep(),
+			}},
+			want: []string{"start_step"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateScenario(&tt.scenario)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var verr *ScenarioValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("err = %v, want *ScenarioValidationError", err)
+			}
+			if !errors.Is(err, ErrInvalidScenario) {
+				t.Errorf("error does not match ErrInvalidScenario")
+			}
+			var paths []string
+			for _, p := range verr.Problems {
+				paths = append(paths, p.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("problem paths = %q, want %q\n%v", paths, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestEntryStep(t *testing.T) {
+	s := &Scenario{Steps: map[string]Step{
+		"ask":  msgStep(to("done")),
+		"done": {Type: StepMessage, ErrorStep: "ask"},
+		"root": msgStep(to("ask")),
+	}}
+	got, err := s.EntryStep()
+	if err != nil || got != "root" {
+		t.Fatalf("EntryStep() = %q, %v; want root", got, err)
+	}
+}
@@ -0,0 +1,368 @@
+package maxbotapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioDocument сценарий, прочитанный из YAML. Документ помнит
+// исходное дерево, поэтому при повторной записи комментарии переносятся
+// на те же ключи, если они сохранились в сценарии, а ключи сохраняют
+// исходный порядок.
+type ScenarioDocument struct {
+	Scenario *Scenario
+	source   *yaml.Node
+}
+
+// ReadScenarioYAML разбирает сценарий в формате YAML.
+// Ключи совпадают с JSON-представлением сценария.
+func ReadScenarioYAML(data []byte) (*ScenarioDocument, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("error parsing scenario YAML: %w", err)
+	}
+
+	var generic interface{}
+	if err := root.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("error decoding scenario YAML: %w", err)
+	}
+	raw, err := json.Marshal(generic)
+	if err != nil {
+		return nil, fmt.Errorf("error converting scenario YAML: %w", err)
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(raw, &scenario); err != nil {
+		return nil, fmt.Errorf("error decoding scenario: %w", err)
+	}
+
+	return &ScenarioDocument{Scenario: &scenario, source: &root}, nil
+}
+
+// YAML сериализует сценарий документа, перенося комментарии и порядок
+// ключей исходного файла
+func (d *ScenarioDocument) YAML() ([]byte, error) {
+	raw, err := json.Marshal(d.Scenario)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling scenario: %w", err)
+	}
+
+	node, err := jsonToYAMLNode(raw)
+	if err != nil {
+		return nil, err
+	}
+	pruneZeroValues(node)
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}
+	if d.source != nil {
+		orderLikeSource(doc, d.source)
+		copyComments(doc, d.source)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("error encoding scenario YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalScenarioYAML сериализует сценарий в YAML
+func MarshalScenarioYAML(s *Scenario) ([]byte, error) {
+	return (&ScenarioDocument{Scenario: s}).YAML()
+}
+
+// UnmarshalScenarioYAML разбирает сценарий из YAML
+func UnmarshalScenarioYAML(data []byte) (*Scenario, error) {
+	doc, err := ReadScenarioYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Scenario, nil
+}
+
+// jsonToYAMLNode строит дерево YAML из JSON, сохраняя порядок ключей
+func jsonToYAMLNode(data []byte) (*yaml.Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	node, err := readJSONValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("error converting scenario to YAML: %w", err)
+	}
+	return node, nil
+}
+
+func readJSONValue(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("unexpected object key %v", keyTok)
+				}
+				value, err := readJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+			}
+			_, err := dec.Token()
+			return node, err
+		case '[':
+			node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for dec.More() {
+				value, err := readJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, value)
+			}
+			_, err := dec.Token()
+			return node, err
+		default:
+			return nil, fmt.Errorf("unexpected delimiter %v", v)
+		}
+	case string:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+		if strings.Contains(v, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+		return node, nil
+	case json.Number:
+		tag := "!!float"
+		if _, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}, nil
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token %v", tok)
+	}
+}
+
+// yamlSchema описывает известные поля структуры для pruneZeroValues
+type yamlSchema struct {
+	fields map[string]*yamlSchema // поля структуры; nil для скалярных и свободных полей
+	items  *yamlSchema            // элементы среза или значения отображения с ключами пользователя
+}
+
+var (
+	nextStepYAMLSchema = &yamlSchema{fields: map[string]*yamlSchema{
+		"condition": nil, "step_id": nil,
+	}}
+	stepYAMLSchema = &yamlSchema{fields: map[string]*yamlSchema{
+		"id": nil, "type": nil, "payload": nil, "timeout": nil, "error_step": nil,
+		"next_steps": {items: nextStepYAMLSchema},
+	}}
+	variableYAMLSchema = &yamlSchema{fields: map[string]*yamlSchema{
+		"name": nil, "type": nil, "description": nil, "required": nil, "default": nil,
+	}}
+	settingsYAMLSchema = &yamlSchema{fields: map[string]*yamlSchema{
+		"timeout": nil, "allow_interruption": nil, "restartable": nil,
+	}}
+	scenarioYAMLSchema = &yamlSchema{fields: map[string]*yamlSchema{
+		"id": nil, "name": nil, "description": nil, "version": nil, "start_step": nil,
+		"steps":      {items: stepYAMLSchema},
+		"variables":  {items: variableYAMLSchema},
+		"settings":   settingsYAMLSchema,
+		"metadata":   nil,
+		"created_at": nil, "updated_at": nil,
+	}}
+)
+
+// Поля со свободным содержимым: убираются, только если равны null
+var opaqueYAMLKeys = map[string]bool{
+	"payload":  true,
+	"metadata": true,
+}
+
+// Поля time.Time: нулевое время сериализуется непустой строкой
+var timeYAMLKeys = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+const zeroYAMLTime = "0001-01-01T00:00:00Z"
+
+// pruneZeroValues убирает пустые и нулевые значения известных полей
+// сценария, чтобы YAML содержал только заданные автором поля. При обратном
+// чтении они восстанавливаются нулевыми значениями Go. Ключи отображений
+// steps и variables, неизвестные поля и содержимое payload и metadata
+// не затрагиваются.
+func pruneZeroValues(node *yaml.Node) {
+	pruneYAMLNode(node, scenarioYAMLSchema)
+}
+
+func pruneYAMLNode(node *yaml.Node, schema *yamlSchema) {
+	if schema == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if schema.items != nil {
+			for _, item := range node.Content {
+				pruneYAMLNode(item, schema.items)
+			}
+		}
+	case yaml.MappingNode:
+		if schema.items != nil {
+			for i := 1; i < len(node.Content); i += 2 {
+				pruneYAMLNode(node.Content[i], schema.items)
+			}
+			return
+		}
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if child, known := schema.fields[key.Value]; known {
+				pruneYAMLNode(value, child)
+				if isZeroYAMLField(key.Value, value) {
+					continue
+				}
+			}
+			content = append(content, key, value)
+		}
+		node.Content = content
+	}
+}
+
+func isZeroYAMLField(key string, node *yaml.Node) bool {
+	if opaqueYAMLKeys[key] {
+		return node.Tag == "!!null"
+	}
+	if timeYAMLKeys[key] && node.Kind == yaml.ScalarNode && node.Value == zeroYAMLTime {
+		return true
+	}
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(node.Content) == 0
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!null":
+			return true
+		case "!!str":
+			return node.Value == ""
+		case "!!int", "!!float":
+			return node.Value == "0"
+		case "!!bool":
+			return node.Value == "false"
+		}
+	}
+	return false
+}
+
+// copyComments переносит комментарии из src в dst для совпадающих путей:
+// ключей отображений и индексов последовательностей
+func copyComments(dst, src *yaml.Node) {
+	if dst == nil || src == nil {
+		return
+	}
+	if dst.HeadComment == "" {
+		dst.HeadComment = src.HeadComment
+	}
+	if dst.LineComment == "" {
+		dst.LineComment = src.LineComment
+	}
+	if dst.FootComment == "" {
+		dst.FootComment = src.FootComment
+	}
+
+	switch {
+	case dst.Kind == yaml.DocumentNode && src.Kind == yaml.DocumentNode:
+		if len(dst.Content) > 0 && len(src.Content) > 0 {
+			copyComments(dst.Content[0], src.Content[0])
+		}
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			for j := 0; j+1 < len(src.Content); j += 2 {
+				if dst.Content[i].Value == src.Content[j].Value {
+					copyComments(dst.Content[i], src.Content[j])
+					copyComments(dst.Content[i+1], src.Content[j+1])
+					break
+				}
+			}
+		}
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			copyComments(dst.Content[i], src.Content[i])
+		}
+	}
+}
+
+// orderLikeSource переставляет ключи отображений dst в порядке
+// совпадающих ключей src. Новые ключи идут после них в прежнем порядке.
+// Так шаги и переменные не сортируются по алфавиту при повторной записи.
+func orderLikeSource(dst, src *yaml.Node) {
+	if dst == nil || src == nil {
+		return
+	}
+
+	switch {
+	case dst.Kind == yaml.DocumentNode && src.Kind == yaml.DocumentNode:
+		if len(dst.Content) > 0 && len(src.Content) > 0 {
+			orderLikeSource(dst.Content[0], src.Content[0])
+		}
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		pairs := make(map[string][2]*yaml.Node, len(dst.Content)/2)
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			pairs[dst.Content[i].Value] = [2]*yaml.Node{dst.Content[i], dst.Content[i+1]}
+		}
+
+		content := make([]*yaml.Node, 0, len(dst.Content))
+		for j := 0; j+1 < len(src.Content); j += 2 {
+			pair, ok := pairs[src.Content[j].Value]
+			if !ok {
+				continue
+			}
+			delete(pairs, src.Content[j].Value)
+			orderLikeSource(pair[1], src.Content[j+1])
+			content = append(content, pair[0], pair[1])
+		}
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			if _, left := pairs[dst.Content[i].Value]; left {
+				content = append(content, dst.Content[i], dst.Content[i+1])
+			}
+		}
+		dst.Content = content
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			orderLikeSource(dst.Content[i], src.Content[i])
+		}
+	}
+}
+
+// ReadScenario читает сценарий в формате JSON или YAML. JSON является
+// подмножеством YAML, поэтому формат определять не нужно.
+func ReadScenario(r io.Reader) (*ScenarioDocument, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading scenario: %w", err)
+	}
+	return ReadScenarioYAML(data)
+}
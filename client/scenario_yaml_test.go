@@ -0,0 +1,111 @@
+package maxbotapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScenarioYAMLRoundTrip(t *testing.T) {
+	src := &Scenario{
+		Name:      "survey",
+		StartStep: "ask",
+		Steps: map[string]Step{
+			"ask":   {Type: StepInput, Payload: json.RawMessage(`{"text":"","variable":"flag","retries":0}`), NextSteps: []NextStep{{}, to("empty")}},
+			"empty": {},
+		},
+		Variables: map[string]Variable{
+			"flag":  {},
+			"since": {Type: VariableString, Default: "0001-01-01T00:00:00Z"},
+		},
+		Metadata:  json.RawMessage(`{"owner":"","tags":[]}`),
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	data, err := MarshalScenarioYAML(src)
+	if err != nil {
+		t.Fatalf("MarshalScenarioYAML: %v", err)
+	}
+	text := string(data)
+	for _, want := range []string{"flag: {}", "empty: {}", "- {}", "retries: 0", "owner: \"\"", "default: \"0001-01-01T00:00:00Z\"", "created_at:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("YAML lacks %q:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"updated_at", "settings", "description", "error_step"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("YAML keeps zero field %q:\n%s", unwanted, text)
+		}
+	}
+
+	got, err := UnmarshalScenarioYAML(data)
+	if err != nil {
+		t.Fatalf("UnmarshalScenarioYAML: %v", err)
+	}
+	if !reflect.DeepEqual(got.Variables, src.Variables) {
+		t.Errorf("Variables = %+v, want %+v", got.Variables, src.Variables)
+	}
+	if !reflect.DeepEqual(got.Steps["ask"].NextSteps, src.Steps["ask"].NextSteps) {
+		t.Errorf("NextSteps = %+v", got.Steps["ask"].NextSteps)
+	}
+	if _, ok := got.Steps["empty"]; !ok {
+		t.Error("empty step lost")
+	}
+	if !sameJSON(t, got.Steps["ask"].Payload, src.Steps["ask"].Payload) || !sameJSON(t, got.Metadata, src.Metadata) {
+		t.Errorf("payload or metadata changed: %s, %s", got.Steps["ask"].Payload, got.Metadata)
+	}
+	if !got.CreatedAt.Equal(src.CreatedAt) {
+		t.Errorf("CreatedAt = %v", got.CreatedAt)
+	}
+}
+
+// sameJSON сравнивает документы JSON без учёта порядка ключей
+func sameJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestScenarioYAMLKeepsCommentsAndOrder(t *testing.T) {
+	doc, src := readScenarioFile(t, "survey.yaml")
+
+	got, err := doc.YAML()
+	if err != nil {
+		t.Fatalf("YAML: %v", err)
+	}
+	if string(got) != string(src) {
+		t.Errorf("round trip changed the document\ngot:\n%s\nwant:\n%s", got, src)
+	}
+}
+
+func TestScenarioYAMLAppendsNewKeys(t *testing.T) {
+	doc, _ := readScenarioFile(t, "survey.yaml")
+	doc.Scenario.Steps["apology"] = textStep("Sorry")
+	delete(doc.Scenario.Steps, "bye")
+
+	data, err := doc.YAML()
+	if err != nil {
+		t.Fatalf("YAML: %v", err)
+	}
+	text := string(data)
+	order := []string{"  # приветствие\n  welcome:", "  ask_rating:", "  thanks:", "  complaint:", "  apology:"}
+	last := -1
+	for _, key := range order {
+		i := strings.Index(text, key)
+		if i <= last {
+			t.Fatalf("key %q out of order:\n%s", key, text)
+		}
+		last = i
+	}
+	if strings.Contains(text, "\n  bye:") {
+		t.Errorf("removed step kept:\n%s", text)
+	}
+}
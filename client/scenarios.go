@@ -0,0 +1,143 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ScenarioVersion опубликованная версия сценария
+type ScenarioVersion struct {
+	Version     string    `json:"version"`
+	Comment     string    `json:"comment,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+func (c *Client) ListScenarios(ctx context.Context) ([]Scenario, error) {
+	url := fmt.Sprintf("%s/api/%s/scenarios", c.baseURL, apiVersion)
+
+	var scenarios []Scenario
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &scenarios)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scenarios, nil
+}
+
+func (c *Client) GetScenario(ctx context.Context, scenarioID string) (*Scenario, error) {
+	if err := checkScenarioID(scenarioID); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/scenarios/%s", c.baseURL, apiVersion, scenarioID)
+
+	var scenario Scenario
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &scenario)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &scenario, nil
+}
+
+// CreateScenario создаёт черновик сценария и возвращает его с присвоенным ID.
+// Запрос не повторяется: после таймаута или 5xx сервер мог уже создать
+// сценарий, и повтор породил бы дубликат.
+func (c *Client) CreateScenario(ctx context.Context, scenario *Scenario) (*Scenario, error) {
+	if scenario == nil {
+		return nil, fmt.Errorf("%w: scenario is nil", ErrInvalidScenario)
+	}
+	url := fmt.Sprintf("%s/api/%s/scenarios", c.baseURL, apiVersion)
+
+	var created Scenario
+	err := c.sendRequest(ctx, "POST", url, scenario, &created)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// UpdateScenario сохраняет изменения черновика сценария scenario.ID.
+// Запущенные сессии продолжают работать на опубликованной версии.
+func (c *Client) UpdateScenario(ctx context.Context, scenario *Scenario) (*Scenario, error) {
+	if scenario == nil {
+		return nil, fmt.Errorf("%w: scenario is nil", ErrInvalidScenario)
+	}
+	if err := checkScenarioID(scenario.ID); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/scenarios/%s", c.baseURL, apiVersion, scenario.ID)
+
+	var updated Scenario
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "PUT", url, scenario, &updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (c *Client) DeleteScenario(ctx context.Context, scenarioID string) error {
+	if err := checkScenarioID(scenarioID); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/%s/scenarios/%s", c.baseURL, apiVersion, scenarioID)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "DELETE", url, nil, nil)
+	})
+}
+
+// PublishScenario публикует текущий черновик как новую версию сценария.
+// Запрос не повторяется: повтор после таймаута мог бы опубликовать
+// вторую версию.
+func (c *Client) PublishScenario(ctx context.Context, scenarioID string, comment string) (*ScenarioVersion, error) {
+	if err := checkScenarioID(scenarioID); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/scenarios/%s/publish", c.baseURL, apiVersion, scenarioID)
+	body := struct {
+		Comment string `json:"comment,omitempty"`
+	}{
+		Comment: comment,
+	}
+
+	var version ScenarioVersion
+	err := c.sendRequest(ctx, "POST", url, body, &version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+func (c *Client) ListScenarioVersions(ctx context.Context, scenarioID string) ([]ScenarioVersion, error) {
+	if err := checkScenarioID(scenarioID); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/scenarios/%s/versions", c.baseURL, apiVersion, scenarioID)
+
+	var versions []ScenarioVersion
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &versions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// checkScenarioID не даёт пустому ID превратить путь сценария в путь коллекции
+func checkScenarioID(scenarioID string) error {
+	if scenarioID == "" {
+		return ErrInvalidScenarioID
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package maxbotapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNonIdempotentScenarioCallsAreNotRetried(t *testing.T) {
+	posts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		posts++
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"code": 502, "message": "bad gateway"}`))
+	})
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"CreateScenario": func() error {
+			_, err := c.CreateScenario(ctx, &Scenario{Name: "x"})
+			return err
+		},
+		"PublishScenario": func() error {
+			_, err := c.PublishScenario(ctx, "s1", "")
+			return err
+		},
+	}
+	for name, call := range calls {
+		posts = 0
+		if err := call(); err == nil {
+			t.Errorf("%s succeeded on 502", name)
+		}
+		if posts != 1 {
+			t.Errorf("%s: %d POST requests, want 1", name, posts)
+		}
+	}
+}
+
+func TestScenarioMethodsRejectEmptyID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"DeleteScenario": func() error { return c.DeleteScenario(ctx, "") },
+		"GetScenario": func() error {
+			_, err := c.GetScenario(ctx, "")
+			return err
+		},
+		"UpdateScenario": func() error {
+			_, err := c.UpdateScenario(ctx, &Scenario{})
+			return err
+		},
+		"PublishScenario": func() error {
+			_, err := c.PublishScenario(ctx, "", "")
+			return err
+		},
+		"ListScenarioVersions": func() error {
+			_, err := c.ListScenarioVersions(ctx, "")
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrInvalidScenarioID) {
+			t.Errorf("%s: err = %v, want ErrInvalidScenarioID", name, err)
+		}
+	}
+
+	if _, err := c.UpdateScenario(ctx, nil); !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("UpdateScenario(nil): err = %v, want ErrInvalidScenario", err)
+	}
+	if _, err := c.CreateScenario(ctx, nil); !errors.Is(err, ErrInvalidScenario) {
+		t.Errorf("CreateScenario(nil): err = %v, want ErrInvalidScenario", err)
+	}
+}
@@ -0,0 +1,147 @@
+package maxbotapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+func (c *Client) GetSession(ctx context.Context, sessionID string) (*ScenarioSession, error) {
+	if err := checkSessionID(sessionID); err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/%s/sessions/%s", c.baseURL, apiVersion, sessionID)
+
+	var session ScenarioSession
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ListChatSessions возвращает сессии сценариев в чате.
+// Если статусы не указаны, возвращаются только активные сессии.
+func (c *Client) ListChatSessions(ctx context.Context, chatID string, statuses ...SessionStatus) ([]ScenarioSession, error) {
+	if chatID == "" {
+		return nil, ErrInvalidChatID
+	}
+	if len(statuses) == 0 {
+		statuses = []SessionStatus{SessionActive}
+	}
+	params := url.Values{}
+	for _, status := range statuses {
+		params.Add("status", string(status))
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/sessions?%s", c.baseURL, apiVersion, chatID, params.Encode())
+
+	var sessions []ScenarioSession
+	err := c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "GET", url, nil, &sessions)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// JumpToStep переводит активную сессию на шаг stepID
+func (c *Client) JumpToStep(ctx context.Context, sessionID string, stepID string) error {
+	session, err := c.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.Status.Final() {
+		return fmt.Errorf("%w: session %s is %s", ErrSessionState, sessionID, session.Status)
+	}
+	if len(session.Scenario.Steps) > 0 {
+		if _, ok := session.Scenario.Steps[stepID]; !ok {
+			return fmt.Errorf("scenario %s has no step %q", session.Scenario.ID, stepID)
+		}
+	}
+
+	return c.sessionAction(ctx, sessionID, "jump", struct {
+		StepID string `json:"step_id"`
+	}{
+		StepID: stepID,
+	})
+}
+
+// UpdateSessionState дописывает значения в State сессии
+func (c *Client) UpdateSessionState(ctx context.Context, sessionID string, state map[string]string) error {
+	if err := checkSessionID(sessionID); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/%s/sessions/%s/state", c.baseURL, apiVersion, sessionID)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "PATCH", url, state, nil)
+	})
+}
+
+// PauseSession приостанавливает сессию. Разрешено, только если
+// в настройках сценария включён AllowInterruption.
+func (c *Client) PauseSession(ctx context.Context, sessionID string) error {
+	session, err := c.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.Scenario.Settings.AllowInterruption {
+		return fmt.Errorf("%w: %s", ErrNotInterruptible, session.Scenario.ID)
+	}
+	if session.Status != SessionActive {
+		return fmt.Errorf("%w: cannot pause %s session", ErrSessionState, session.Status)
+	}
+	return c.sessionAction(ctx, sessionID, "pause", nil)
+}
+
+// ResumeSession продолжает приостановленную сессию с текущего шага.
+// Как и PauseSession, разрешено, только если в настройках сценария
+// включён AllowInterruption.
+func (c *Client) ResumeSession(ctx context.Context, sessionID string) error {
+	session, err := c.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.Scenario.Settings.AllowInterruption {
+		return fmt.Errorf("%w: %s", ErrNotInterruptible, session.Scenario.ID)
+	}
+	if session.Status != SessionPaused {
+		return fmt.Errorf("%w: cannot resume %s session", ErrSessionState, session.Status)
+	}
+	return c.sessionAction(ctx, sessionID, "resume", nil)
+}
+
+// RestartSession запускает сценарий сессии заново с первого шага.
+// Разрешено, только если сценарий помечен как Restartable.
+func (c *Client) RestartSession(ctx context.Context, sessionID string) error {
+	session, err := c.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.Scenario.Settings.Restartable {
+		return fmt.Errorf("%w: %s", ErrNotRestartable, session.Scenario.ID)
+	}
+	return c.sessionAction(ctx, sessionID, "restart", nil)
+}
+
+func (c *Client) sessionAction(ctx context.Context, sessionID string, action string, body interface{}) error {
+	if err := checkSessionID(sessionID); err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/api/%s/sessions/%s/%s", c.baseURL, apiVersion, sessionID, action)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "POST", url, body, nil)
+	})
+}
+
+// checkSessionID не даёт пустому ID превратить путь сессии в путь коллекции
+func checkSessionID(sessionID string) error {
+	if sessionID == "" {
+		return ErrInvalidSessionID
+	}
+	return nil
+}
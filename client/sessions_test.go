@@ -0,0 +1,164 @@
+package maxbotapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"testing"
+)
+
+// newSessionServer отдаёт session на GET и записывает действия POST
+func newSessionServer(t *testing.T, session ScenarioSession, actions *[]string) *Client {
+	t.Helper()
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			json.NewEncoder(w).Encode(session)
+		case "POST":
+			*actions = append(*actions, path.Base(r.URL.Path))
+			w.Write([]byte("{}"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	interruptible := ScenarioSettings{AllowInterruption: true}
+	scenario := func(settings ScenarioSettings) Scenario {
+		return Scenario{ID: "sc", Settings: settings, Steps: map[string]Step{"a": msgStep(), "b": msgStep()}}
+	}
+
+	tests := []struct {
+		name       string
+		status     SessionStatus
+		settings   ScenarioSettings
+		call       func(c *Client) error
+		wantErr    error
+		wantAction string
+	}{
+		{
+			name: "pause active", status: SessionActive, settings: interruptible,
+			call:       func(c *Client) error { return c.PauseSession(context.Background(), "s1") },
+			wantAction: "pause",
+		},
+		{
+			name: "pause not interruptible", status: SessionActive,
+			call:    func(c *Client) error { return c.PauseSession(context.Background(), "s1") },
+			wantErr: ErrNotInterruptible,
+		},
+		{
+			name: "pause paused", status: SessionPaused, settings: interruptible,
+			call:    func(c *Client) error { return c.PauseSession(context.Background(), "s1") },
+			wantErr: ErrSessionState,
+		},
+		{
+			name: "resume paused", status: SessionPaused, settings: interruptible,
+			call:       func(c *Client) error { return c.ResumeSession(context.Background(), "s1") },
+			wantAction: "resume",
+		},
+		{
+			name: "resume not interruptible", status: SessionPaused,
+			call:    func(c *Client) error { return c.ResumeSession(context.Background(), "s1") },
+			wantErr: ErrNotInterruptible,
+		},
+		{
+			name: "resume active", status: SessionActive, settings: interruptible,
+			call:    func(c *Client) error { return c.ResumeSession(context.Background(), "s1") },
+			wantErr: ErrSessionState,
+		},
+		{
+			name: "restart restartable", status: SessionCompleted, settings: ScenarioSettings{Restartable: true},
+			call:       func(c *Client) error { return c.RestartSession(context.Background(), "s1") },
+			wantAction: "restart",
+		},
+		{
+			name: "restart not restartable", status: SessionActive,
+			call:    func(c *Client) error { return c.RestartSession(context.Background(), "s1") },
+			wantErr: ErrNotRestartable,
+		},
+		{
+			name: "jump to known step", status: SessionActive,
+			call:       func(c *Client) error { return c.JumpToStep(context.Background(), "s1", "b") },
+			wantAction: "jump",
+		},
+		{
+			name: "jump in finished session", status: SessionCompleted,
+			call:    func(c *Client) error { return c.JumpToStep(context.Background(), "s1", "b") },
+			wantErr: ErrSessionState,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actions []string
+			c := newSessionServer(t, ScenarioSession{ID: "s1", Status: tt.status, Scenario: scenario(tt.settings)}, &actions)
+
+			err := tt.call(c)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(actions) != 0 {
+					t.Errorf("actions %q sent after rejected call", actions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(actions) != 1 || actions[0] != tt.wantAction {
+				t.Errorf("actions = %q, want [%s]", actions, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestJumpToUnknownStep(t *testing.T) {
+	var actions []string
+	c := newSessionServer(t, ScenarioSession{ID: "s1", Status: SessionActive, Scenario: Scenario{
+		Steps: map[string]Step{"a": msgStep()},
+	}}, &actions)
+
+	if err := c.JumpToStep(context.Background(), "s1", "missing"); err == nil {
+		t.Fatal("JumpToStep accepted an unknown step")
+	}
+	if len(actions) != 0 {
+		t.Errorf("actions %q sent for unknown step", actions)
+	}
+}
+
+func TestSessionMethodsRejectEmptyID(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+
+	calls := map[string]func() error{
+		"GetSession": func() error {
+			_, err := c.GetSession(ctx, "")
+			return err
+		},
+		"UpdateSessionState": func() error { return c.UpdateSessionState(ctx, "", map[string]string{"a": "1"}) },
+		"JumpToStep":         func() error { return c.JumpToStep(ctx, "", "a") },
+		"PauseSession":       func() error { return c.PauseSession(ctx, "") },
+		"ResumeSession":      func() error { return c.ResumeSession(ctx, "") },
+		"RestartSession":     func() error { return c.RestartSession(ctx, "") },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrInvalidSessionID) {
+			t.Errorf("%s: err = %v, want ErrInvalidSessionID", name, err)
+		}
+	}
+
+	if err := c.StopScenario(ctx, "chat", ""); !errors.Is(err, ErrInvalidScenarioID) {
+		t.Errorf("StopScenario: err = %v, want ErrInvalidScenarioID", err)
+	}
+	if err := c.StopScenario(ctx, "", "sc"); !errors.Is(err, ErrInvalidChatID) {
+		t.Errorf("StopScenario: err = %v, want ErrInvalidChatID", err)
+	}
+	if _, err := c.ListChatSessions(ctx, ""); !errors.Is(err, ErrInvalidChatID) {
+		t.Errorf("ListChatSessions: err = %v, want ErrInvalidChatID", err)
+	}
+}
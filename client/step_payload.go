@@ -0,0 +1,212 @@
+package maxbotapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StepPayload типизированная полезная нагрузка шага сценария
+type StepPayload interface {
+	// StepType возвращает тип шага, к которому относится нагрузка
+	StepType() string
+	Validate() error
+}
+
+// MessagePayload нагрузка шага StepMessage. В Text можно подставлять
+// значения состояния сессии: {{name}}.
+type MessagePayload struct {
+	Text    string     `json:"text"`
+	Format  TextFormat `json:"format,omitempty"`
+	Buttons [][]Button `json:"buttons,omitempty"`
+}
+
+func (MessagePayload) StepType() string { return StepMessage }
+
+func (p MessagePayload) Validate() error {
+	if strings.TrimSpace(p.Text) == "" {
+		return fmt.Errorf("text is required")
+	}
+	if len(p.Buttons) > 0 {
+		return ValidateKeyboard(p.Buttons)
+	}
+	return nil
+}
+
+// InputPayload нагрузка шага StepInput: Text отправляется как вопрос,
+// ответ пользователя сохраняется в состояние под именем Variable
+type InputPayload struct {
+	Text     string `json:"text,omitempty"`
+	Variable string `json:"variable"`
+}
+
+func (InputPayload) StepType() string { return StepInput }
+
+func (p InputPayload) Validate() error {
+	if p.Variable == "" {
+		return fmt.Errorf("variable is required")
+	}
+	return nil
+}
+
+// ConditionPayload нагрузка шага StepCondition. Ветвление задаётся
+// условиями в NextSteps, сама нагрузка необязательна.
+type ConditionPayload struct {
+	Description string `json:"description,omitempty"`
+}
+
+func (ConditionPayload) StepType() string { return StepCondition }
+
+func (ConditionPayload) Validate() error { return nil }
+
+// APICallPayload нагрузка шага StepAPICall. Ответ сервиса сохраняется
+// в состояние под именем ResultVariable.
+type APICallPayload struct {
+	Method         string            `json:"method,omitempty"` // по умолчанию GET
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           json.RawMessage   `json:"body,omitempty"`
+	ResultVariable string            `json:"result_variable,omitempty"`
+}
+
+func (APICallPayload) StepType() string { return StepAPICall }
+
+func (p APICallPayload) Validate() error {
+	if p.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	// URL может содержать подстановки {{name}}, поэтому проверяется только схема
+	if u, err := url.Parse(p.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("url must be an absolute http(s) URL, got %q", p.URL)
+	}
+	switch strings.ToUpper(p.Method) {
+	case "", "GET", "POST", "PUT", "PATCH", "DELETE":
+	default:
+		return fmt.Errorf("unsupported method %q", p.Method)
+	}
+	return nil
+}
+
+// TransferPayload нагрузка шага StepTransfer
+type TransferPayload struct {
+	TransferOptions
+}
+
+func (TransferPayload) StepType() string { return StepTransfer }
+
+func (TransferPayload) Validate() error { return nil }
+
+// DelayPayload нагрузка шага StepDelay
+type DelayPayload struct {
+	Seconds int `json:"seconds"`
+}
+
+func (DelayPayload) StepType() string { return StepDelay }
+
+func (p DelayPayload) Validate() error {
+	if p.Seconds < 0 {
+		return fmt.Errorf("seconds must not be negative, got %d", p.Seconds)
+	}
+	return nil
+}
+
+var (
+	stepPayloadsMu sync.RWMutex
+	stepPayloads   = map[string]func() StepPayload{
+		StepMessage:   func() StepPayload { return &MessagePayload{} },
+		StepInput:     func() StepPayload { return &InputPayload{} },
+		StepCondition: func() StepPayload { return &ConditionPayload{} },
+		StepAPICall:   func() StepPayload { return &APICallPayload{} },
+		StepTransfer:  func() StepPayload { return &TransferPayload{} },
+		StepDelay:     func() StepPayload { return &DelayPayload{} },
+	}
+)
+
+// RegisterStepPayload регистрирует тип шага и конструктор его нагрузки.
+// Повторная регистрация заменяет прежний конструктор, в том числе
+// для встроенных типов.
+func RegisterStepPayload(stepType string, factory func() StepPayload) {
+	stepPayloadsMu.Lock()
+	defer stepPayloadsMu.Unlock()
+	stepPayloads[stepType] = factory
+}
+
+// StepTypes возвращает зарегистрированные типы шагов
+func StepTypes() []string {
+	stepPayloadsMu.RLock()
+	defer stepPayloadsMu.RUnlock()
+
+	types := make([]string, 0, len(stepPayloads))
+	for t := range stepPayloads {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func stepPayloadFactory(stepType string) (func() StepPayload, bool) {
+	stepPayloadsMu.RLock()
+	defer stepPayloadsMu.RUnlock()
+	factory, ok := stepPayloads[stepType]
+	return factory, ok
+}
+
+// DecodeStepPayload разбирает нагрузку шага по зарегистрированному типу.
+// Пустая нагрузка даёт нулевое значение структуры.
+func DecodeStepPayload(stepType string, raw json.RawMessage) (StepPayload, error) {
+	factory, ok := stepPayloadFactory(stepType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStepType, stepType)
+	}
+
+	payload := factory()
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, payload); err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", stepType, err)
+		}
+	}
+	return payload, nil
+}
+
+// DecodePayload разбирает Payload шага по его типу
+func (s Step) DecodePayload() (StepPayload, error) {
+	payload, err := DecodeStepPayload(s.Type, s.Payload)
+	if err != nil && s.ID != "" {
+		return nil, fmt.Errorf("step %s: %w", s.ID, err)
+	}
+	return payload, err
+}
+
+// DecodePayload разбирает Payload шага по его типу
+func (s ScenarioStep) DecodePayload() (StepPayload, error) {
+	raw, err := json.Marshal(s.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("step %s: %w", s.ID, err)
+	}
+	payload, err := DecodeStepPayload(s.Type, raw)
+	if err != nil {
+		return nil, fmt.Errorf("step %s: %w", s.ID, err)
+	}
+	return payload, nil
+}
+
+// StepResult результат выполнения шага, который хранится в StepExecution.Payload
+type StepResult struct {
+	Input string `json:"input,omitempty"` // ответ пользователя на шаге StepInput
+	Error string `json:"error,omitempty"` // ошибка, по которой шаг завершился
+}
+
+// Result разбирает результат выполнения шага
+func (x StepExecution) Result() (*StepResult, error) {
+	var result StepResult
+	if len(x.Payload) == 0 || string(x.Payload) == "null" {
+		return &result, nil
+	}
+	if err := json.Unmarshal(x.Payload, &result); err != nil {
+		return nil, fmt.Errorf("step %s: invalid result: %w", x.StepID, err)
+	}
+	return &result, nil
+}
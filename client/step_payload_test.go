@@ -0,0 +1,118 @@
+package maxbotapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// pollPayload нагрузка пользовательского типа шага для тестов реестра
+type pollPayload struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+func (pollPayload) StepType() string { return "poll" }
+
+func (p pollPayload) Validate() error {
+	if len(p.Options) < 2 {
+		return fmt.Errorf("poll needs at least 2 options")
+	}
+	return nil
+}
+
+// registerTestPayload регистрирует тип шага до конца теста
+func registerTestPayload(t *testing.T, stepType string, factory func() StepPayload) {
+	t.Helper()
+	previous, existed := stepPayloadFactory(stepType)
+	RegisterStepPayload(stepType, factory)
+	t.Cleanup(func() {
+		stepPayloadsMu.Lock()
+		defer stepPayloadsMu.Unlock()
+		if existed {
+			stepPayloads[stepType] = previous
+		} else {
+			delete(stepPayloads, stepType)
+		}
+	})
+}
+
+func TestDecodeStepPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		stepType string
+		raw      string
+		want     StepPayload
+		wantErr  error
+	}{
+		{"message", StepMessage, `{"text":"hi","format":"markdown"}`, &MessagePayload{Text: "hi", Format: FormatMarkdown}, nil},
+		{"input", StepInput, `{"variable":"age"}`, &InputPayload{Variable: "age"}, nil},
+		{"empty payload", StepDelay, ``, &DelayPayload{}, nil},
+		{"null payload", StepCondition, `null`, &ConditionPayload{}, nil},
+		{"transfer", StepTransfer, `{"group_id":"support"}`, &TransferPayload{TransferOptions{GroupID: "support"}}, nil},
+		{"unknown type", "poll", `{}`, nil, ErrUnknownStepType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeStepPayload(tt.stepType, json.RawMessage(tt.raw))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeStepPayload: %v", err)
+			}
+			if fmt.Sprintf("%#v", got) != fmt.Sprintf("%#v", tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Step{ID: "greet", Type: StepMessage, Payload: json.RawMessage(`{"text":1}`)}).DecodePayload(); err == nil {
+		t.Error("DecodePayload accepted a number as text")
+	}
+}
+
+func TestRegisterStepPayload(t *testing.T) {
+	registerTestPayload(t, "poll", func() StepPayload { return &pollPayload{} })
+
+	if !slices.Contains(StepTypes(), "poll") {
+		t.Errorf("StepTypes() = %q, lacks poll", StepTypes())
+	}
+	payload, err := DecodeStepPayload("poll", json.RawMessage(`{"question":"tea?","options":["yes","no"]}`))
+	if err != nil {
+		t.Fatalf("DecodeStepPayload: %v", err)
+	}
+	if p, ok := payload.(*pollPayload); !ok || p.Question != "tea?" || len(p.Options) != 2 {
+		t.Errorf("payload = %#v", payload)
+	}
+
+	scenario := &Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask": {Type: "poll", Payload: json.RawMessage(`{"question":"tea?","options":["yes"]}`)},
+	}}
+	var verr *ScenarioValidationError
+	if err := ValidateScenario(scenario); !errors.As(err, &verr) || len(verr.Problems) != 1 || verr.Problems[0].Path != "steps.ask.payload" {
+		t.Errorf("ValidateScenario = %v, want one payload problem", err)
+	}
+	if _, err := NewEngine(&Scenario{StartStep: "ask", Steps: map[string]Step{
+		"ask": {Type: "poll", Payload: json.RawMessage(`{"options":["yes","no"]}`)},
+	}}, newFakeSender()); !errors.As(err, &verr) {
+		t.Errorf("NewEngine accepted a custom step type: %v", err)
+	}
+}
+
+func TestRegisterStepPayloadReplacesBuiltin(t *testing.T) {
+	registerTestPayload(t, StepDelay, func() StepPayload { return &pollPayload{} })
+
+	payload, err := DecodeStepPayload(StepDelay, json.RawMessage(`{"options":["a","b"]}`))
+	if err != nil {
+		t.Fatalf("DecodeStepPayload: %v", err)
+	}
+	if _, ok := payload.(*pollPayload); !ok {
+		t.Errorf("payload = %T, want *pollPayload", payload)
+	}
+}
@@ -6,19 +6,60 @@ import (
 )
 
 type MessageResponse struct {
-	ID        string    `json:"id"`
-	Timestamp time.Time `json:"timestamp"`
-	Status    string    `json:"status"`
+	ID        string         `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Status    DeliveryStatus `json:"status"`
+}
+
+// DeliveryStatus статус доставки исходящего сообщения
+type DeliveryStatus string
+
+const (
+	DeliveryQueued    DeliveryStatus = "queued"
+	DeliverySent      DeliveryStatus = "sent"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryRead      DeliveryStatus = "read"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivered сообщает, дошло ли сообщение до получателя
+func (s DeliveryStatus) Delivered() bool {
+	return s == DeliveryDelivered || s == DeliveryRead
+}
+
+// Final сообщает, что статус больше не изменится
+func (s DeliveryStatus) Final() bool {
+	return s == DeliveryRead || s == DeliveryFailed
+}
+
+// MessageStatus состояние доставки сообщения; приходит в ответ
+// на GetMessageStatus и в событиях EventMessageStatus
+type MessageStatus struct {
+	MessageID string         `json:"message_id"`
+	ChatID    string         `json:"chat_id"`
+	Status    DeliveryStatus `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
 }
 
 type ChatInfo struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Status    string    `json:"status"`
-	User      User      `json:"user"`
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Status    ChatStatus `json:"status"`
+	User      User       `json:"user"`
 }
 
+// ChatStatus статус чата
+type ChatStatus string
+
+const (
+	ChatOpen     ChatStatus = "open"
+	ChatClosed   ChatStatus = "closed"
+	ChatResolved ChatStatus = "resolved"
+	ChatArchived ChatStatus = "archived"
+)
+
 type User struct {
 	ID        string `json:"id"`
 	FirstName string `json:"first_name"`
@@ -27,13 +68,27 @@ type User struct {
 }
 
 type Message struct {
-	ID        string          `json:"id"`
-	ChatID    string          `json:"chat_id"`
-	Text      string          `json:"text"`
-	Direction string          `json:"direction"`
-	Type      string          `json:"type"`
-	Payload   json.RawMessage `json:"payload"`
-	CreatedAt time.Time       `json:"created_at"`
+	ID          string               `json:"id"`
+	ChatID      string               `json:"chat_id"`
+	Text        string               `json:"text"`
+	Direction   string               `json:"direction"`
+	Type        string               `json:"type"`
+	Payload     json.RawMessage      `json:"payload"`
+	Attachments []IncomingAttachment `json:"attachments,omitempty"`
+	ReplyToID   string               `json:"reply_to_id,omitempty"`
+	ReplyTo     *Message             `json:"reply_to,omitempty"` // цитируемое сообщение
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// IncomingAttachment описывает файл во входящем сообщении.
+// Содержимое скачивается через Client.DownloadAttachment.
+type IncomingAttachment struct {
+	ID       string         `json:"id"`
+	Type     AttachmentType `json:"type"`
+	FileName string         `json:"file_name,omitempty"`
+	MimeType string         `json:"mime_type,omitempty"`
+	Size     int64          `json:"size,omitempty"`
+	URL      string         `json:"url,omitempty"`
 }
 
 type WebhookEvent struct {
@@ -52,21 +107,24 @@ type EventData struct {
 }
 
 type Chat struct {
-	ID        string            `json:"id"`
-	Type      string            `json:"type"`
-	Status    string            `json:"status"`
-	User      User              `json:"user"`
-	Variables map[string]string `json:"variables"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Status    ChatStatus `json:"status"`
+	User      User       `json:"user"`
+	Variables Variables  `json:"variables"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 type TextMessage struct {
-	Text string `json:"text"`
+	Text    string `json:"text"`
+	ReplyTo string `json:"reply_to_id,omitempty"`
 }
 
 type ImageMessage struct {
-	ImageURL string `json:"image_url"`
+	ImageURL string `json:"image_url,omitempty"`
+	Token    string `json:"token,omitempty"` // токен файла, загруженного через UploadImage
+	ReplyTo  string `json:"reply_to_id,omitempty"`
 }
 
 // type Button struct {
@@ -77,6 +135,56 @@ type ImageMessage struct {
 type ButtonsMessage struct {
 	Text    string   `json:"text"`
 	Buttons []Button `json:"buttons"`
+	ReplyTo string   `json:"reply_to_id,omitempty"`
+}
+
+// KeyboardMessage текстовое сообщение с клавиатурой из нескольких рядов кнопок
+type KeyboardMessage struct {
+	Text    string     `json:"text"`
+	Buttons [][]Button `json:"buttons"`
+	ReplyTo string     `json:"reply_to_id,omitempty"`
+}
+
+// CarouselMessage сообщение с каруселью карточек
+type CarouselMessage struct {
+	Carousel []CarouselItem `json:"carousel"`
+	ReplyTo  string         `json:"reply_to_id,omitempty"`
+}
+
+// TextFormat определяет разметку текста сообщения
+type TextFormat string
+
+const (
+	FormatPlain    TextFormat = ""
+	FormatMarkdown TextFormat = "markdown"
+	FormatHTML     TextFormat = "html"
+)
+
+// AttachmentType тип вложения
+type AttachmentType string
+
+const (
+	AttachmentImage AttachmentType = "image"
+	AttachmentVideo AttachmentType = "video"
+	AttachmentAudio AttachmentType = "audio"
+	AttachmentFile  AttachmentType = "file"
+)
+
+// Attachment вложение исходящего сообщения: ссылка или токен загруженного файла
+type Attachment struct {
+	Type  AttachmentType `json:"type"`
+	Token string         `json:"token,omitempty"`
+	URL   string         `json:"url,omitempty"`
+}
+
+// RichMessage сообщение с разметкой, клавиатурой и вложениями.
+// Обычно создаётся через NewMessage.
+type RichMessage struct {
+	Text        string       `json:"text,omitempty"`
+	Format      TextFormat   `json:"format,omitempty"`
+	Buttons     [][]Button   `json:"buttons,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	ReplyTo     string       `json:"reply_to_id,omitempty"`
 }
 
 // Структуры для работы со сценариями
@@ -95,12 +203,25 @@ type ScenarioStep struct {
 
 // Структуры для клавиатур и каруселей
 type Button struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-	Type  string `json:"type"` // "text", "url", etc.
-	Value string `json:"value"`
+	ID    string     `json:"id"`
+	Title string     `json:"title"`
+	Type  ButtonType `json:"type"`
+	Value string     `json:"value"`
 }
 
+// ButtonType тип кнопки клавиатуры
+type ButtonType string
+
+const (
+	ButtonText            ButtonType = "text"
+	ButtonCallback        ButtonType = "callback"
+	ButtonURL             ButtonType = "url"
+	ButtonRequestContact  ButtonType = "request_contact"
+	ButtonRequestLocation ButtonType = "request_location"
+	ButtonOpenApp         ButtonType = "open_app"
+	ButtonStartScenario   ButtonType = "start_scenario"
+)
+
 type CarouselItem struct {
 	Title       string   `json:"title"`
 	Description string   `json:"description"`
@@ -109,8 +230,11 @@ type CarouselItem struct {
 }
 
 // Структуры для управления чатами
+
+// ChatVariables тело ответа GET /chats/{id}/variables. Запросы PUT и PATCH
+// передают сами переменные без обёртки: {"name": "value"}.
 type ChatVariables struct {
-	Variables map[string]interface{} `json:"variables"`
+	Variables Variables `json:"variables"`
 }
 
 type TransferOptions struct {
@@ -124,17 +248,20 @@ type LocationMessage struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 	Title     string  `json:"title,omitempty"`
+	ReplyTo   string  `json:"reply_to_id,omitempty"`
 }
 
 type ContactMessage struct {
 	PhoneNumber string `json:"phone_number"`
 	FirstName   string `json:"first_name"`
 	LastName    string `json:"last_name,omitempty"`
+	ReplyTo     string `json:"reply_to_id,omitempty"`
 }
 
 type TemplateMessage struct {
 	TemplateID string                 `json:"template_id"`
 	Variables  map[string]interface{} `json:"variables,omitempty"`
+	ReplyTo    string                 `json:"reply_to_id,omitempty"`
 }
 
 // Chat соответствует интерфейсу IChat из TS
@@ -164,12 +291,29 @@ type ScenarioSession struct {
 	ID          string            `json:"id"`
 	Scenario    Scenario          `json:"scenario"`
 	Chat        Chat              `json:"chat"`
+	Status      SessionStatus     `json:"status"`
 	State       map[string]string `json:"state"`
 	CurrentStep StepExecution     `json:"current_step"`
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
+// SessionStatus статус сессии сценария
+type SessionStatus string
+
+const (
+	SessionActive    SessionStatus = "active"
+	SessionPaused    SessionStatus = "paused"
+	SessionCompleted SessionStatus = "completed"
+	SessionFailed    SessionStatus = "failed"
+	SessionCancelled SessionStatus = "cancelled"
+)
+
+// Final сообщает, что сессия завершена и больше не изменится
+func (s SessionStatus) Final() bool {
+	return s == SessionCompleted || s == SessionFailed || s == SessionCancelled
+}
+
 // WebhookEvent соответствует IWebhookEvent из TS
 // type WebhookEvent struct {
 // 	EventID   string          `json:"event_id"`
@@ -186,6 +330,7 @@ type Scenario struct {
 	Name        string              `json:"name"`
 	Description string              `json:"description"`
 	Version     string              `json:"version"`
+	StartStep   string              `json:"start_step,omitempty"` // если пуст, первым считается шаг без входящих переходов
 	Steps       map[string]Step     `json:"steps"`
 	Variables   map[string]Variable `json:"variables"`
 	Settings    ScenarioSettings    `json:"settings"`
@@ -197,13 +342,23 @@ type Scenario struct {
 // Step представляет шаг сценария
 type Step struct {
 	ID        string          `json:"id"`
-	Type      string          `json:"type"` // "message", "input", "condition", etc.
+	Type      string          `json:"type"` // StepMessage, StepInput, StepCondition, etc.
 	Payload   json.RawMessage `json:"payload"`
 	NextSteps []NextStep      `json:"next_steps"`
 	Timeout   int             `json:"timeout"`
 	ErrorStep string          `json:"error_step"`
 }
 
+// Типы шагов сценария (Step.Type)
+const (
+	StepMessage   = "message"
+	StepInput     = "input"
+	StepCondition = "condition"
+	StepAPICall   = "api_call"
+	StepTransfer  = "transfer"
+	StepDelay     = "delay"
+)
+
 // NextStep определяет переход между шагами
 type NextStep struct {
 	Condition string `json:"condition"`
@@ -0,0 +1,250 @@
+package maxbotapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Максимальные размеры загружаемых файлов по типам вложений
+var uploadSizeLimits = map[AttachmentType]int64{
+	AttachmentImage: 10 << 20,
+	AttachmentVideo: 2 << 30,
+	AttachmentAudio: 100 << 20,
+	AttachmentFile:  2 << 30,
+}
+
+// UploadOptions параметры загрузки файла
+type UploadOptions struct {
+	// FileName имя файла, передаваемое серверу
+	FileName string
+	// ContentType MIME-тип файла; если пуст, определяется по первым байтам,
+	// а если они не подходят для типа вложения, по расширению FileName
+	ContentType string
+	// Size размер файла, если известен заранее; используется для проверки
+	// лимита до начала загрузки и передаётся в Progress
+	Size int64
+	// Progress вызывается по мере отправки данных
+	Progress func(uploaded, total int64)
+}
+
+// UploadImage загружает изображение и возвращает вложение для исходящих сообщений
+func (c *Client) UploadImage(ctx context.Context, r io.Reader, opts UploadOptions) (*Attachment, error) {
+	return c.UploadFile(ctx, AttachmentImage, r, opts)
+}
+
+// UploadVideo загружает видео
+func (c *Client) UploadVideo(ctx context.Context, r io.Reader, opts UploadOptions) (*Attachment, error) {
+	return c.UploadFile(ctx, AttachmentVideo, r, opts)
+}
+
+// UploadAudio загружает аудио
+func (c *Client) UploadAudio(ctx context.Context, r io.Reader, opts UploadOptions) (*Attachment, error) {
+	return c.UploadFile(ctx, AttachmentAudio, r, opts)
+}
+
+// UploadDocument загружает документ произвольного типа
+func (c *Client) UploadDocument(ctx context.Context, r io.Reader, opts UploadOptions) (*Attachment, error) {
+	return c.UploadFile(ctx, AttachmentFile, r, opts)
+}
+
+// UploadFile потоково загружает файл из r как multipart/form-data.
+// Тело читается из потока, поэтому запрос не повторяется при ошибках.
+func (c *Client) UploadFile(ctx context.Context, kind AttachmentType, r io.Reader, opts UploadOptions) (*Attachment, error) {
+	limit, ok := uploadSizeLimits[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown attachment type %q", ErrUnsupportedMedia, kind)
+	}
+	if opts.Size > limit {
+		return nil, fmt.Errorf("%w: %d bytes, max %d for %s", ErrFileTooLarge, opts.Size, limit, kind)
+	}
+
+	br := bufio.NewReaderSize(r, 512)
+	contentType := opts.ContentType
+	if contentType == "" {
+		head, err := br.Peek(512)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		contentType = detectContentType(kind, head, opts.FileName)
+	}
+	if !mediaTypeAllowed(kind, contentType) {
+		return nil, fmt.Errorf("%w: %s is not allowed for %s", ErrUnsupportedMedia, contentType, kind)
+	}
+
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = string(kind)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeUploadBody(mw, br, fileName, contentType, limit, opts))
+	}()
+
+	params := url.Values{}
+	params.Set("type", string(kind))
+	url := fmt.Sprintf("%s/api/%s/uploads?%s", c.baseURL, apiVersion, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, pr)
+	if err != nil {
+		pr.Close()
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	c.logger.Debug("Uploading file",
+		zap.String("type", string(kind)),
+		zap.String("contentType", contentType),
+		zap.String("fileName", fileName),
+	)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, ErrRateLimit
+	}
+	if resp.StatusCode == http.StatusRequestEntityTooLarge {
+		return nil, fmt.Errorf("%w: rejected by server", ErrFileTooLarge)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, c.parseAPIError(resp)
+	}
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	if result.Token == "" {
+		return nil, fmt.Errorf("upload response has no token")
+	}
+
+	c.logger.Info("File uploaded", zap.String("type", string(kind)))
+	return &Attachment{Type: kind, Token: result.Token}, nil
+}
+
+func writeUploadBody(mw *multipart.Writer, r io.Reader, fileName, contentType string, limit int64, opts UploadOptions) error {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     "file",
+		"filename": fileName,
+	}))
+	header.Set("Content-Type", contentType)
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	src := &progressReader{r: r, total: opts.Size, limit: limit, progress: opts.Progress}
+	if _, err := io.Copy(part, src); err != nil {
+		return err
+	}
+	return mw.Close()
+}
+
+// progressReader считает прочитанные байты, сообщает прогресс
+// и прерывает чтение при превышении лимита
+type progressReader struct {
+	r        io.Reader
+	read     int64
+	total    int64
+	limit    int64
+	progress func(uploaded, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.read > p.limit {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrFileTooLarge, p.limit)
+	}
+	if n > 0 && p.progress != nil {
+		p.progress(p.read, p.total)
+	}
+	return n, err
+}
+
+// Типы по расширению для форматов, которые http.DetectContentType
+// не распознаёт или относит к другому виду вложения
+var extensionMediaTypes = map[string]string{
+	".aac":  "audio/aac",
+	".flac": "audio/flac",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".oga":  "audio/ogg",
+	".ogg":  "audio/ogg",
+	".opus": "audio/ogg",
+	".wav":  "audio/wav",
+	".weba": "audio/webm",
+	".3gp":  "video/3gpp",
+	".m4v":  "video/mp4",
+	".mkv":  "video/x-matroska",
+	".mov":  "video/quicktime",
+	".mp4":  "video/mp4",
+	".ogv":  "video/ogg",
+	".webm": "video/webm",
+}
+
+// detectContentType определяет MIME-тип по первым байтам файла. Если он
+// не подходит для kind (m4a распознаётся как video/mp4, а многие форматы
+// только как application/octet-stream), тип берётся по расширению fileName.
+// Контейнеры mp4, ogg и webm общие для звука и видео, поэтому для аудио
+// тип video/* из таблицы заменяется на audio/*.
+func detectContentType(kind AttachmentType, head []byte, fileName string) string {
+	sniffed := http.DetectContentType(head)
+	if mediaTypeAllowed(kind, sniffed) {
+		return sniffed
+	}
+
+	ext := strings.ToLower(path.Ext(fileName))
+	byExt, ok := extensionMediaTypes[ext]
+	if !ok {
+		byExt = mime.TypeByExtension(ext)
+	}
+	if kind == AttachmentAudio {
+		if subtype, ok := strings.CutPrefix(byExt, "video/"); ok {
+			byExt = "audio/" + subtype
+		}
+	}
+	if byExt != "" && mediaTypeAllowed(kind, byExt) {
+		return byExt
+	}
+	return sniffed
+}
+
+func mediaTypeAllowed(kind AttachmentType, contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	switch kind {
+	case AttachmentImage:
+		return strings.HasPrefix(mediaType, "image/")
+	case AttachmentVideo:
+		return strings.HasPrefix(mediaType, "video/")
+	case AttachmentAudio:
+		return strings.HasPrefix(mediaType, "audio/") || mediaType == "application/ogg"
+	default:
+		return mediaType != ""
+	}
+}
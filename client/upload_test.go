@@ -0,0 +1,39 @@
+package maxbotapi
+
+import "testing"
+
+func TestDetectContentType(t *testing.T) {
+	var (
+		m4a  = []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00M4A mp42isom\x00\x00\x00\x00")
+		ogg  = []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00")
+		webm = []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\xF7\x81\x01")
+		png  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+		raw  = []byte{0x01, 0x02, 0x03, 0x04}
+	)
+
+	tests := []struct {
+		name     string
+		kind     AttachmentType
+		head     []byte
+		fileName string
+		want     string
+	}{
+		{"m4a audio", AttachmentAudio, m4a, "voice.m4a", "audio/mp4"},
+		{"ogg audio", AttachmentAudio, ogg, "voice.ogg", "application/ogg"},
+		{"opus without magic", AttachmentAudio, raw, "voice.OPUS", "audio/ogg"},
+		{"webm audio", AttachmentAudio, webm, "voice.webm", "audio/webm"},
+		{"webm video", AttachmentVideo, webm, "clip.webm", "video/webm"},
+		{"mkv video", AttachmentVideo, raw, "clip.mkv", "video/x-matroska"},
+		{"sniffed wins", AttachmentImage, png, "photo.m4a", "image/png"},
+		{"no extension", AttachmentAudio, raw, "voice", "application/octet-stream"},
+		{"extension of other kind", AttachmentImage, raw, "voice.m4a", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectContentType(tt.kind, tt.head, tt.fileName)
+			if got != tt.want {
+				t.Errorf("detectContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
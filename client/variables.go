@@ -0,0 +1,238 @@
+package maxbotapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Variables переменные чата. Сервер хранит значения строками,
+// поэтому один и тот же тип используется при чтении и записи,
+// а типизированные значения читаются и пишутся через методы.
+type Variables map[string]string
+
+// Get возвращает значение переменной и признак её наличия
+func (v Variables) Get(name string) (string, bool) {
+	value, ok := v[name]
+	return value, ok
+}
+
+// String возвращает значение переменной или def, если её нет
+func (v Variables) String(name string, def string) string {
+	if value, ok := v[name]; ok {
+		return value
+	}
+	return def
+}
+
+func (v Variables) Int(name string) (int64, error) {
+	value, err := v.lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("variable %s: %w", name, err)
+	}
+	return n, nil
+}
+
+func (v Variables) Float(name string) (float64, error) {
+	value, err := v.lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("variable %s: %w", name, err)
+	}
+	return f, nil
+}
+
+func (v Variables) Bool(name string) (bool, error) {
+	value, err := v.lookup(name)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("variable %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// Time разбирает значение переменной в формате RFC 3339
+func (v Variables) Time(name string) (time.Time, error) {
+	value, err := v.lookup(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("variable %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func (v Variables) SetString(name string, value string) {
+	v[name] = value
+}
+
+func (v Variables) SetInt(name string, value int64) {
+	v[name] = strconv.FormatInt(value, 10)
+}
+
+func (v Variables) SetFloat(name string, value float64) {
+	v[name] = strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func (v Variables) SetBool(name string, value bool) {
+	v[name] = strconv.FormatBool(value)
+}
+
+func (v Variables) SetTime(name string, value time.Time) {
+	v[name] = value.Format(time.RFC3339)
+}
+
+func (v Variables) lookup(name string) (string, error) {
+	value, ok := v[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrVariableNotFound, name)
+	}
+	return value, nil
+}
+
+const (
+	// Число попыток UpdateChatVariables при конфликте версий
+	maxConflictRetries = 5
+	// Пауза перед второй попыткой; перед каждой следующей удваивается
+	conflictRetryDelay = 50 * time.Millisecond
+)
+
+// GetChatVariables возвращает все переменные чата
+func (c *Client) GetChatVariables(ctx context.Context, chatID string) (Variables, error) {
+	variables, _, err := c.GetChatVariablesVersion(ctx, chatID)
+	return variables, err
+}
+
+// GetChatVariablesVersion возвращает переменные чата и их версию (ETag)
+// для последующего условного обновления через SetChatVariablesIfMatch
+func (c *Client) GetChatVariablesVersion(ctx context.Context, chatID string) (Variables, string, error) {
+	url := fmt.Sprintf("%s/api/%s/chats/%s/variables", c.baseURL, apiVersion, chatID)
+
+	var result ChatVariables
+	var version string
+	err := c.retryRequest(ctx, func() error {
+		header, err := c.sendRequestWithHeader(ctx, "GET", url, nil, &result, nil)
+		if err != nil {
+			return err
+		}
+		version = header.Get("ETag")
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Пустой набор приходит как {"variables": {}}; отсутствие поля означает
+	// неожиданный ответ, и подставлять пустой набор нельзя: UpdateChatVariables
+	// записал бы его обратно поверх настоящих значений
+	if result.Variables == nil {
+		return nil, "", fmt.Errorf("chat %s: response has no variables field", chatID)
+	}
+	return result.Variables, version, nil
+}
+
+// SetChatVariablesIfMatch заменяет переменные, только если их версия
+// на сервере совпадает с version. Иначе возвращает ErrVersionConflict.
+// Возвращает новую версию.
+func (c *Client) SetChatVariablesIfMatch(ctx context.Context, chatID string, variables Variables, version string) (string, error) {
+	if version == "" {
+		return "", fmt.Errorf("version is required for conditional update")
+	}
+	url := fmt.Sprintf("%s/api/%s/chats/%s/variables", c.baseURL, apiVersion, chatID)
+
+	header := http.Header{}
+	header.Set("If-Match", version)
+
+	var newVersion string
+	err := c.retryRequest(ctx, func() error {
+		respHeader, err := c.sendRequestWithHeader(ctx, "PUT", url, variables, nil, header)
+		if err != nil {
+			return err
+		}
+		newVersion = respHeader.Get("ETag")
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return newVersion, nil
+}
+
+// UpdateChatVariables читает переменные, применяет к ним update и записывает
+// их условно, повторяя цикл при конфликте версий не более maxConflictRetries
+// раз с растущей паузой. Если update возвращает ошибку, запись не выполняется.
+func (c *Client) UpdateChatVariables(ctx context.Context, chatID string, update func(Variables) error) (Variables, error) {
+	var err error
+	delay := conflictRetryDelay
+	for i := 0; i < maxConflictRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		var variables Variables
+		var version string
+		variables, version, err = c.GetChatVariablesVersion(ctx, chatID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := update(variables); err != nil {
+			return nil, err
+		}
+
+		_, err = c.SetChatVariablesIfMatch(ctx, chatID, variables, version)
+		if err == nil {
+			return variables, nil
+		}
+		if !errors.Is(err, ErrVersionConflict) {
+			return nil, err
+		}
+
+		c.logger.Debug("Chat variables version conflict, retrying",
+			zap.String("chatID", chatID),
+			zap.Int("attempt", i+1),
+		)
+	}
+
+	return nil, fmt.Errorf("chat %s: giving up after %d attempts: %w", chatID, maxConflictRetries, err)
+}
+
+// PatchChatVariables изменяет только переданные переменные, остальные сохраняются
+func (c *Client) PatchChatVariables(ctx context.Context, chatID string, variables Variables) error {
+	url := fmt.Sprintf("%s/api/%s/chats/%s/variables", c.baseURL, apiVersion, chatID)
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "PATCH", url, variables, nil)
+	})
+}
+
+// DeleteChatVariable удаляет одну переменную чата
+func (c *Client) DeleteChatVariable(ctx context.Context, chatID string, name string) error {
+	url := fmt.Sprintf("%s/api/%s/chats/%s/variables/%s", c.baseURL, apiVersion, chatID, url.PathEscape(name))
+	return c.retryRequest(ctx, func() error {
+		return c.sendRequest(ctx, "DELETE", url, nil, nil)
+	})
+}
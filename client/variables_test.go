@@ -0,0 +1,173 @@
+package maxbotapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatVariablesWireShape(t *testing.T) {
+	stored := Variables{"name": "Ann", "city": "Kazan"}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("ETag", `"v1"`)
+			json.NewEncoder(w).Encode(ChatVariables{Variables: stored})
+		case "PUT":
+			var body map[string]json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("PUT body: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if _, wrapped := body["variables"]; wrapped {
+				t.Errorf("PUT body is wrapped in a variables field")
+			}
+			stored = Variables{}
+			for name, raw := range body {
+				var value string
+				if err := json.Unmarshal(raw, &value); err != nil {
+					t.Errorf("PUT variable %s is not a string: %s", name, raw)
+				}
+				stored[name] = value
+			}
+			w.Header().Set("ETag", `"v2"`)
+			w.Write([]byte("{}"))
+		}
+	})
+
+	vars, err := c.UpdateChatVariables(context.Background(), "chat", func(v Variables) error {
+		v.SetInt("age", 30)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateChatVariables: %v", err)
+	}
+	for _, name := range []string{"name", "city", "age"} {
+		if _, ok := stored[name]; !ok {
+			t.Errorf("stored variables lost %q: %v", name, stored)
+		}
+	}
+	if vars["age"] != "30" {
+		t.Errorf("age = %q, want 30", vars["age"])
+	}
+}
+
+func TestGetChatVariablesRejectsUnknownBody(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("unexpected %s after unrecognised GET body", r.Method)
+		}
+		w.Write([]byte(`{"name": "Ann"}`))
+	})
+
+	if _, err := c.GetChatVariables(context.Background(), "chat"); err == nil {
+		t.Fatal("GetChatVariables accepted a body without variables field")
+	}
+	_, err := c.UpdateChatVariables(context.Background(), "chat", func(Variables) error { return nil })
+	if err == nil {
+		t.Fatal("UpdateChatVariables wrote variables after unrecognised GET body")
+	}
+}
+
+func TestUpdateChatVariablesRetriesConflict(t *testing.T) {
+	for _, status := range []int{http.StatusPreconditionFailed, http.StatusConflict} {
+		puts := 0
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case "GET":
+				w.Header().Set("ETag", `"v1"`)
+				json.NewEncoder(w).Encode(ChatVariables{Variables: Variables{}})
+			case "PUT":
+				puts++
+				if puts == 1 {
+					w.WriteHeader(status)
+					w.Write([]byte(`{"code": 1, "message": "version mismatch"}`))
+					return
+				}
+				w.Write([]byte("{}"))
+			}
+		})
+
+		_, err := c.UpdateChatVariables(context.Background(), "chat", func(v Variables) error {
+			v.SetBool("ok", true)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("status %d: UpdateChatVariables: %v", status, err)
+		}
+		if puts != 2 {
+			t.Errorf("status %d: %d PUT requests, want 2", status, puts)
+		}
+	}
+}
+
+func TestChatVariablesWriteBody(t *testing.T) {
+	var bodies []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s body: %v", r.Method, err)
+		}
+		bodies = append(bodies, r.Method+" "+body["name"])
+		w.Write([]byte("{}"))
+	})
+	ctx := context.Background()
+
+	if err := c.SetChatVariables(ctx, "chat", Variables{"name": "Ann"}); err != nil {
+		t.Fatalf("SetChatVariables: %v", err)
+	}
+	if err := c.PatchChatVariables(ctx, "chat", Variables{"name": "Bob"}); err != nil {
+		t.Fatalf("PatchChatVariables: %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != "PUT Ann" || bodies[1] != "PATCH Bob" {
+		t.Errorf("bodies = %q, want bare variable maps", bodies)
+	}
+}
+
+func TestConflictWithoutIfMatchIsAPIError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"code": 409, "message": "already exists"}`))
+	})
+
+	err := c.sendRequest(context.Background(), "POST", c.baseURL+"/api/v1/scenarios", Scenario{Name: "x"}, nil)
+	if err == nil || errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("plain 409 = %v, want API error without ErrVersionConflict", err)
+	}
+	if !strings.Contains(err.Error(), "409") {
+		t.Errorf("err = %v, want API error 409", err)
+	}
+}
+
+func TestUpdateChatVariablesGivesUp(t *testing.T) {
+	puts := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("ETag", `"v1"`)
+			json.NewEncoder(w).Encode(ChatVariables{Variables: Variables{}})
+		case "PUT":
+			puts++
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"code": 412, "message": "version mismatch"}`))
+		}
+	})
+
+	start := time.Now()
+	_, err := c.UpdateChatVariables(context.Background(), "chat", func(Variables) error { return nil })
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("err = %v, want ErrVersionConflict", err)
+	}
+	if puts != maxConflictRetries {
+		t.Errorf("%d PUT requests, want %d", puts, maxConflictRetries)
+	}
+	// паузы 50+100+200+400 мс между попытками
+	if elapsed := time.Since(start); elapsed < 15*conflictRetryDelay {
+		t.Errorf("attempts took %v, want backoff of at least %v", elapsed, 15*conflictRetryDelay)
+	}
+}
@@ -0,0 +1,87 @@
+package maxbotapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func signBody(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookParseRequest(t *testing.T) {
+	const secret = "hook-secret"
+	const body = `{"type":"message","chat":{"id":"c1"},"message":{"text":"hi"}}`
+
+	tests := []struct {
+		name      string
+		method    string
+		signature string
+		body      string
+		wantErr   error // nil, если запрос должен разобраться
+		anyErr    bool  // ошибка без отдельного sentinel
+	}{
+		{name: "valid", method: "POST", signature: signBody(secret, body), body: body},
+		{name: "wrong secret", method: "POST", signature: signBody("other", body), body: body, wantErr: ErrSignatureInvalid},
+		{name: "tampered body", method: "POST", signature: signBody(secret, body), body: strings.Replace(body, "hi", "bye", 1), wantErr: ErrSignatureInvalid},
+		{name: "uppercase hex", method: "POST", signature: strings.ToUpper(signBody(secret, body)), body: body, wantErr: ErrSignatureInvalid},
+		{name: "missing signature", method: "POST", body: body, anyErr: true},
+		{name: "wrong method", method: "GET", signature: signBody(secret, body), body: body, anyErr: true},
+	}
+	wh := NewWebhookHandler(secret, zap.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/hook", strings.NewReader(tt.body))
+			if tt.signature != "" {
+				r.Header.Set("X-Signature", tt.signature)
+			}
+
+			event, err := wh.ParseRequest(r)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseRequest() = %v, want %v", err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil {
+					t.Fatal("ParseRequest() succeeded")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("ParseRequest: %v", err)
+				}
+				if event.Type != EventMessage || event.Chat.ID != "c1" {
+					t.Errorf("event = %+v", event)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookHandleRejectsBadSignature(t *testing.T) {
+	wh := NewWebhookHandler("hook-secret", zap.NewNop())
+	called := false
+	handler := wh.Handle(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	r := httptest.NewRequest("POST", "/hook", strings.NewReader(`{"type":"message"}`))
+	r.Header.Set("X-Signature", signBody("other", `{"type":"message"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if called {
+		t.Error("next handler called for a bad signature")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", w.Code)
+	}
+}
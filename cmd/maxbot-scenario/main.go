@@ -0,0 +1,165 @@
+// Команда maxbot-scenario проверяет и конвертирует сценарии MAX Bot.
+//
+//	maxbot-scenario validate scenario.yaml
+//	maxbot-scenario to-yaml scenario.json > scenario.yaml
+//	maxbot-scenario to-json scenario.yaml > scenario.json
+//	maxbot-scenario dot scenario.yaml | dot -Tsvg > scenario.svg
+//	maxbot-scenario mermaid scenario.yaml
+//	maxbot-scenario diff old.yaml new.yaml
+//
+// Вместо имени файла можно указать "-" для чтения из stdin.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	maxbotapi "github.com/mozgozjegatel/max-bot-go/client"
+)
+
+const usage = `usage: maxbot-scenario <command> [file|-]
+       maxbot-scenario diff <old> <new>
+
+commands:
+  validate   check scenario structure and print problems
+  to-yaml    convert scenario to YAML
+  to-json    convert scenario to JSON
+  dot        export step graph in Graphviz DOT format
+  mermaid    export step graph as Mermaid flowchart
+  diff       show structural changes between two scenario versions
+  help       print this message
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+
+	switch command := flag.Arg(0); {
+	case command == "help":
+		fmt.Print(usage)
+		return
+	case command == "diff":
+		if flag.NArg() != 3 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := diff(flag.Arg(1), flag.Arg(2), os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	case commands[command] == nil:
+		if command != "" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", command)
+		}
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	path := "-"
+	if flag.NArg() == 2 {
+		path = flag.Arg(1)
+	}
+
+	if err := run(flag.Arg(0), path, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// commands команды, работающие с одним сценарием
+var commands = map[string]func(doc *maxbotapi.ScenarioDocument, out io.Writer) error{
+	"validate": func(doc *maxbotapi.ScenarioDocument, out io.Writer) error {
+		err := maxbotapi.ValidateScenario(doc.Scenario)
+		var verr *maxbotapi.ScenarioValidationError
+		if errors.As(err, &verr) {
+			for _, p := range verr.Problems {
+				fmt.Fprintln(out, p)
+			}
+			return fmt.Errorf("%d problem(s) found", len(verr.Problems))
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, "ok")
+		return err
+	},
+	"to-yaml": func(doc *maxbotapi.ScenarioDocument, out io.Writer) error {
+		data, err := doc.YAML()
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	},
+	"to-json": func(doc *maxbotapi.ScenarioDocument, out io.Writer) error {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc.Scenario)
+	},
+	"dot": func(doc *maxbotapi.ScenarioDocument, out io.Writer) error {
+		_, err := io.WriteString(out, maxbotapi.ScenarioDOT(doc.Scenario))
+		return err
+	},
+	"mermaid": func(doc *maxbotapi.ScenarioDocument, out io.Writer) error {
+		_, err := io.WriteString(out, maxbotapi.ScenarioMermaid(doc.Scenario))
+		return err
+	},
+}
+
+// run проверяет команду до чтения сценария, чтобы неизвестная
+// команда не ждала ввода из stdin
+func run(command, path string, out io.Writer) error {
+	fn, ok := commands[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+
+	doc, err := readDocument(path)
+	if err != nil {
+		return err
+	}
+	return fn(doc, out)
+}
+
+func diff(oldPath, newPath string, out io.Writer) error {
+	old, err := readDocument(oldPath)
+	if err != nil {
+		return err
+	}
+	updated, err := readDocument(newPath)
+	if err != nil {
+		return err
+	}
+
+	d := maxbotapi.DiffScenarios(old.Scenario, updated.Scenario)
+	if d.Empty() {
+		fmt.Fprintln(out, "no changes")
+		return nil
+	}
+	_, err = io.WriteString(out, d.String())
+	return err
+}
+
+func readDocument(path string) (*maxbotapi.ScenarioDocument, error) {
+	if path == "-" {
+		return maxbotapi.ReadScenario(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return maxbotapi.ReadScenario(f)
+}